@@ -1,7 +1,6 @@
 package cmd
 
 import (
-  "bytes"
   "io/ioutil"
   "log"
   "os"
@@ -12,48 +11,83 @@ import (
 )
 
 var (
-  pkgName string
-  outDir  string
+  pkgName     string
+  outDir      string
+  scalars     []string
+  enums       []string
+  templateDir string
+  ctxResolver bool
+  endpoint    string
+  headerDefs  []string
+  operations  []string
 )
 
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
-  Use:   "graphql-gen-go",
+  Use:   "graphql-gen-go [file | directory | pattern]...",
   Short: "",
   Long:  ``,
   Run: func(cmd *cobra.Command, args []string) {
-    fileData := &bytes.Buffer{}
+    var files []*generator.SchemaFile
+
+    // the schema of a running endpoint can be extended with local files
+    if endpoint != "" {
+      headers, err := generator.ParseHeaders(headerDefs)
+      check(err)
+      file, err := generator.FetchSchema(endpoint, headers)
+      check(err)
+      files = append(files, file)
+    }
+    if endpoint == "" || len(args) > 0 {
+      local, err := generator.LoadSchema(args)
+      check(err)
+      files = append(files, local...)
+    }
 
-    files := args
+    bindings, err := generator.ParseBindings(append(scalars, enums...))
+    check(err)
 
-    for _, file := range files {
-      f, err := ioutil.ReadFile(file)
-      if err != nil {
-        log.Fatal(err)
-      }
-      fileData.WriteString("\n")
-      fileData.Write(f)
+    targetDir := outDir
+    if pkgName != "main" {
+      targetDir = path.Join(outDir, "/", pkgName)
+    }
+
+    // only the client of the operations is generated when they are given
+    if len(operations) > 0 {
+      documents, err := generator.LoadOperations(operations)
+      check(err)
+      cliGen := generator.New()
+      err = cliGen.ParseFiles(files)
+      check(err)
+      cliOut := cliGen.SetPkgName(pkgName).
+        SetBindings(bindings).
+        SetTemplateDir(templateDir).
+        GenClientFile(documents)
+      mkdir(targetDir)
+      createFile(targetDir, "client.gql.go", cliOut)
+      return
     }
 
     // generate resolver output
     resGen := generator.New()
-    err := resGen.Parse(fileData.Bytes())
+    err = resGen.ParseFiles(files)
     check(err)
-    resOut := resGen.SetPkgName(pkgName).GenSchemaResolversFile()
+    resOut := resGen.SetPkgName(pkgName).
+      SetBindings(bindings).
+      SetTemplateDir(templateDir).
+      SetContextResolvers(ctxResolver).
+      GenSchemaResolversFile()
 
     // generate server output
     srvGen := generator.New()
-    srvOut := srvGen.SetPkgName(pkgName).GenServerFile()
-
-    targetDir := outDir
-    if pkgName != "main" {
-      targetDir = path.Join(outDir, "/", pkgName)
-    }
+    err = srvGen.ParseFiles(files)
+    check(err)
+    srvOut := srvGen.SetPkgName(pkgName).
+      SetTemplateDir(templateDir).
+      SetContextResolvers(ctxResolver).
+      GenServerFile()
 
-    // create directory if it does not exist
-    if _, err = os.Stat(targetDir); os.IsNotExist(err) {
-      os.Mkdir(targetDir, os.ModePerm)
-    }
+    mkdir(targetDir)
 
     // create resolver file
     resFile := pkgName + ".gql.go"
@@ -62,9 +96,34 @@ var RootCmd = &cobra.Command{
     // create server file
     srvFile := "server.gql.go"
     createFile(targetDir, srvFile, srvOut)
+
+    // create stub file of the resolver methods left to the user, or append the new ones to it
+    stubFile := pkgName + "-extra.go"
+    stubSrc, err := ioutil.ReadFile(path.Join(targetDir, stubFile))
+    if err != nil && !os.IsNotExist(err) {
+      log.Fatal(err)
+    }
+    stubGen := generator.New()
+    err = stubGen.ParseFiles(files)
+    check(err)
+    stubOut := stubGen.SetPkgName(pkgName).
+      SetBindings(bindings).
+      SetTemplateDir(templateDir).
+      SetContextResolvers(ctxResolver).
+      GenStubsFile(targetDir, stubSrc)
+    if stubOut != nil {
+      createFile(targetDir, stubFile, stubOut)
+    }
   },
 }
 
+// mkdir creates the directory if it does not exist
+func mkdir(dir string) {
+  if _, err := os.Stat(dir); os.IsNotExist(err) {
+    os.Mkdir(dir, os.ModePerm)
+  }
+}
+
 func createFile(dir, fileName string, out []byte) {
   outFile := path.Join(dir, fileName)
   // open the file and write to it
@@ -88,6 +147,13 @@ func Execute() {
 func init() {
   RootCmd.PersistentFlags().StringVar(&pkgName, "pkg", "main", "generated golang package name")
   RootCmd.PersistentFlags().StringVar(&outDir, "out_dir", "./", "output directory (default is current directory)")
+  RootCmd.PersistentFlags().StringArrayVar(&scalars, "scalar", nil, "map a custom scalar to a go type i.e., UUID=github.com/google/uuid.UUID[,marshal=PKG.FUNC][,unmarshal=PKG.FUNC]")
+  RootCmd.PersistentFlags().BoolVar(&ctxResolver, "context_resolvers", true, "generate resolver methods which take a context.Context and return an error")
+  RootCmd.PersistentFlags().StringVar(&templateDir, "template_dir", "", "directory with templates overriding the default ones i.e., object.tmpl")
+  RootCmd.PersistentFlags().StringVar(&endpoint, "endpoint", "", "url of a running graphql endpoint to generate from with its introspection")
+  RootCmd.PersistentFlags().StringArrayVar(&headerDefs, "header", nil, "http header of the introspection request to the endpoint i.e., 'Authorization: Bearer TOKEN'")
+  RootCmd.PersistentFlags().StringArrayVar(&operations, "operations", nil, "operation files, directories or patterns to generate a typed client of instead of the server i.e., 'queries/*.graphql'")
+  RootCmd.PersistentFlags().StringArrayVar(&enums, "enum", nil, "bind an enum to an existing go type and its constants i.e., Episode=github.com/acme/domain.Episode[,prefix=CONST_PREFIX]")
 }
 
 func check(err error) {
@@ -0,0 +1,158 @@
+package generator
+
+import (
+  "os"
+  "os/exec"
+  "reflect"
+  "strings"
+  "testing"
+)
+
+const argsSchema = `
+schema {
+  query: Query
+}
+
+type Query {
+  person(id: ID!): Person
+  folder(id: ID!): Folder
+}
+
+interface Node {
+  id: ID!
+  children(first: Int): [Node!]!
+}
+
+type Person implements Node {
+  id: ID!
+  children(first: Int): [Node!]!
+  friends(first: Int, after: ID): [Person!]!
+}
+
+type Folder implements Node {
+  id: ID!
+  children(first: Int): [Node!]!
+  friends(owner: Boolean!): [Person!]!
+}
+`
+
+func TestArgsStructs(t *testing.T) {
+  g := newTestGenerator(t, argsSchema)
+
+  args := map[string][]string{}
+  for _, f := range g.resolversData().Args {
+    for _, arg := range f.Args {
+      args[f.ArgsName()] = append(args[f.ArgsName()], arg.Name)
+    }
+  }
+  want := map[string][]string{
+    "QueryPersonArgs":   {"ID"},
+    "QueryFolderArgs":   {"ID"},
+    "NodeChildrenArgs":  {"First"},
+    "PersonFriendsArgs": {"First", "After"},
+    "FolderFriendsArgs": {"Owner"},
+  }
+  if !reflect.DeepEqual(args, want) {
+    t.Errorf("got the arguments structs %v, want %v", args, want)
+  }
+
+  src := string(g.GenSchemaResolversFile())
+  for _, decl := range []string{
+    "type PersonFriendsArgs struct",
+    "type FolderFriendsArgs struct",
+  } {
+    if !strings.Contains(src, decl) {
+      t.Errorf("generated resolvers miss %q", decl)
+    }
+  }
+  if n := strings.Count(src, "Children(ctx context.Context, args NodeChildrenArgs)"); n < 3 {
+    t.Errorf("got %d Children resolver methods taking NodeChildrenArgs, want the ones of Node, Person and Folder", n)
+  }
+  for _, decl := range []string{"PersonChildrenArgs", "FolderChildrenArgs"} {
+    if strings.Contains(src, decl) {
+      t.Errorf("generated resolvers declare %s instead of using NodeChildrenArgs", decl)
+    }
+  }
+}
+
+// TestArgsDefaults checks that the arguments and input fields with a default value are not pointers,
+// as graphql-go never unmarshals null into them
+func TestArgsDefaults(t *testing.T) {
+  g := newTestGenerator(t, `
+schema {
+  query: Query
+}
+
+type Query {
+  people(first: Int = 10, after: ID, filter: Filter = {limit: 5}): [Person!]!
+}
+
+type Person {
+  id: ID!
+}
+
+input Filter {
+  limit: Int = 20
+  name: String
+}
+`)
+  src := string(g.GenSchemaResolversFile())
+  for _, want := range []string{
+    "type QueryPeopleArgs struct {\n\tFirst  int32\n\tAfter  *string\n\tFilter Filter\n}",
+    "type Filter struct {\n\tLimit int32\n\tName  *string\n}",
+  } {
+    if !strings.Contains(src, want) {
+      t.Errorf("generated resolvers miss\n%s", want)
+    }
+  }
+  compileGenerated(t, g, nil)
+}
+
+func TestArgsStructCollisions(t *testing.T) {
+  if schema := os.Getenv("ARGS_COLLISION_SCHEMA"); schema != "" {
+    newTestGenerator(t, schema).resolversData()
+    return
+  }
+
+  for name, test := range map[string]struct {
+    schema string
+    want   string
+  }{
+    "args structs": {
+      schema: `
+schema { query: Query }
+type Query { a: A, ab: AB }
+type A { bC(x: Int): Int }
+type AB { c(x: Int): Int }
+`,
+      want: "arguments struct ABCArgs of AB.C collides with the one of A.BC",
+    },
+    "go types": {
+      schema: `
+schema { query: Query }
+type Query { person: Person, args: PersonFriendsArgs }
+type Person { friends(first: Int): [Person!]! }
+type PersonFriendsArgs { first: Int }
+`,
+      want: "arguments struct PersonFriendsArgs of Person.Friends collides with the go types of type PersonFriendsArgs",
+    },
+  } {
+    t.Run(name, func(t *testing.T) {
+      if out := runFailing(t, "TestArgsStructCollisions", "ARGS_COLLISION_SCHEMA="+test.schema); !strings.Contains(out, test.want) {
+        t.Errorf("got %q, want it to contain %q", out, test.want)
+      }
+    })
+  }
+}
+
+// runFailing runs the test in a subprocess with the environment variable set, as Fail exits the program,
+// and returns its output once it failed
+func runFailing(t *testing.T, test, env string) string {
+  cmd := exec.Command(os.Args[0], "-test.run=^"+test+"$")
+  cmd.Env = append(os.Environ(), env)
+  out, err := cmd.CombinedOutput()
+  if exit, ok := err.(*exec.ExitError); !ok || exit.Success() {
+    t.Fatalf("generator did not fail: %v\n%s", err, out)
+  }
+  return string(out)
+}
@@ -0,0 +1,140 @@
+package generator
+
+import (
+  "errors"
+  "fmt"
+  "strings"
+)
+
+// GoRef references a Go identifier (type or function), optionally declared in another package
+type GoRef struct {
+  Pkg  string
+  Name string
+  // alias is the name the package is imported as, see Bindings.resolveAliases
+  alias string
+}
+
+// parseGoRef parses a qualified identifier like `github.com/google/uuid.UUID`.
+// An identifier without a package path refers to the generated package
+func parseGoRef(s string) GoRef {
+  i := strings.LastIndex(s, ".")
+  if i < strings.LastIndex(s, "/") {
+    i = -1
+  }
+  if i < 0 {
+    return GoRef{Name: s}
+  }
+  return GoRef{Pkg: s[:i], Name: s[i+1:]}
+}
+
+// String returns the identifier as it is referenced from the generated code
+func (r GoRef) String() string {
+  if r.Pkg == "" {
+    return r.Name
+  }
+  return r.pkgName() + "." + r.Name
+}
+
+func (r GoRef) pkgName() string {
+  if r.alias != "" {
+    return r.alias
+  }
+  return pkgName(r.Pkg)
+}
+
+func (r GoRef) IsZero() bool {
+  return r.Name == ""
+}
+
+// Binding maps a GraphQL scalar or enum type to an existing Go type.
+//
+// A bound enum type must have a constant per enum value named `Prefix + Value` (i.e., EpisodeNewHope)
+// where the prefix defaults to the name of the go type.
+//
+// A bound scalar type without marshal functions must implement the graphql-go scalar
+// methods (`ImplementsGraphQLType`, `UnmarshalGraphQL` and json marshalling) itself.
+// Otherwise a wrapper type is generated which converts the values using
+//
+//	func Unmarshal(input interface{}) (T, error)
+//	func Marshal(value T) (interface{}, error)
+type Binding struct {
+  Name      string
+  Type      GoRef
+  Marshal   GoRef
+  Unmarshal GoRef
+  Prefix    string
+}
+
+// ParseBinding parses a binding definition like
+// `UUID=github.com/google/uuid.UUID,marshal=example.com/scalars.MarshalUUID,unmarshal=example.com/scalars.UnmarshalUUID`
+// or `Episode=example.com/domain.Episode,prefix=Episode`
+func ParseBinding(s string) (*Binding, error) {
+  parts := strings.Split(s, ",")
+  kv := strings.SplitN(parts[0], "=", 2)
+  if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+    return nil, fmt.Errorf("invalid binding %q, expected NAME=GO_TYPE", s)
+  }
+
+  b := &Binding{
+    Name: kv[0],
+    Type: parseGoRef(kv[1]),
+  }
+
+  for _, opt := range parts[1:] {
+    kv := strings.SplitN(opt, "=", 2)
+    if len(kv) != 2 || kv[1] == "" {
+      return nil, fmt.Errorf("invalid option %q in binding %q", opt, s)
+    }
+    switch kv[0] {
+    case "marshal":
+      b.Marshal = parseGoRef(kv[1])
+    case "unmarshal":
+      b.Unmarshal = parseGoRef(kv[1])
+    case "prefix":
+      b.Prefix = kv[1]
+    default:
+      return nil, fmt.Errorf("unknown option %q in binding %q", kv[0], s)
+    }
+  }
+
+  if !b.Marshal.IsZero() && b.Unmarshal.IsZero() {
+    return nil, errors.New("binding " + b.Name + " has a marshal function but no unmarshal function")
+  }
+
+  return b, nil
+}
+
+// Wraps reports whether a wrapper type has to be generated for the binding
+func (b *Binding) Wraps() bool {
+  return !b.Unmarshal.IsZero()
+}
+
+// packages returns the import paths referenced by the bindings
+func (b *Binding) packages() []string {
+  var pkgs []string
+  for _, ref := range []GoRef{b.Type, b.Marshal, b.Unmarshal} {
+    if ref.Pkg != "" {
+      pkgs = append(pkgs, ref.Pkg)
+    }
+  }
+  return pkgs
+}
+
+// Bindings maps GraphQL type names to their Go bindings
+type Bindings map[string]*Binding
+
+// ParseBindings parses a list of binding definitions
+func ParseBindings(defs []string) (Bindings, error) {
+  bindings := Bindings{}
+  for _, def := range defs {
+    b, err := ParseBinding(def)
+    if err != nil {
+      return nil, err
+    }
+    if _, exists := bindings[b.Name]; exists {
+      return nil, errors.New("duplicate binding for " + b.Name)
+    }
+    bindings[b.Name] = b
+  }
+  return bindings, nil
+}
@@ -0,0 +1,426 @@
+package generator
+
+import (
+  "strconv"
+  "strings"
+
+  "github.com/dealtap/graphql-gen-go/gqlquery"
+  gqlerrors "github.com/graph-gophers/graphql-go/errors"
+  "github.com/graph-gophers/graphql-go/introspection"
+)
+
+// clientMethods are the methods of the generated Client which the operations must not be named after
+var clientMethods = map[string]bool{"Do": true}
+
+// clientTypes are the go types of the client transport
+var clientTypes = []string{"Client", "NewClient", "ClientError", "ClientErrors", "clientRequest"}
+
+// clientData is the data of the client template
+type clientData struct {
+  Enums      []*TypeDef
+  Scalars    []*TypeDef
+  Inputs     []*ClientStruct
+  Operations []*ClientOperation
+}
+
+// ClientOperation is a method of the generated Client executing an operation of the documents
+type ClientOperation struct {
+  // Name is the go name of the operation i.e., GetPerson
+  Name string
+  // OpName is the name of the operation in the document
+  OpName string
+  // Kind is query or mutation
+  Kind string
+  // Document is the go string literal of the operation and the fragments it uses
+  Document  string
+  Variables *ClientStruct
+  Result    *ClientStruct
+  // Types are the structs of the variables, the result and the nested selections
+  Types []*ClientStruct
+}
+
+// ClientStruct is a struct of the variables, an input type or a selection set of the client
+type ClientStruct struct {
+  Name        string
+  Description string
+  Fields      []*ClientField
+}
+
+type ClientField struct {
+  Name string
+  Type string
+  // Key is the name of the field in the json request or response
+  Key string
+  // OmitEmpty is set for the nullable fields of inputs, which are left out of the request when they are nil
+  OmitEmpty bool
+}
+
+// Tag returns the json struct tag of the field
+func (f *ClientField) Tag() string {
+  if f.OmitEmpty {
+    return "`json:\"" + f.Key + ",omitempty\"`"
+  }
+  return "`json:\"" + f.Key + "\"`"
+}
+
+// GenClientFile generates a typed client for the operations of the documents.
+// The documents are validated against the schema and the errors are reported at their position in the files
+func (g Generator) GenClientFile(documents []*SchemaFile) []byte {
+  src, sources := concatFiles(documents)
+
+  var msgs []string
+  for _, err := range g.schema.Validate(string(src)) {
+    // the values of the variables are only known when the operations are executed
+    if err.Rule == "VariablesOfCorrectType" {
+      continue
+    }
+    msgs = append(msgs, sources.translate(err).Error())
+  }
+  if len(msgs) > 0 {
+    g.Fail("invalid operations:\n" + strings.Join(msgs, "\n"))
+  }
+
+  doc, err := gqlquery.Parse(string(src))
+  if err != nil {
+    g.Error(sources.translate(err), "unable to parse the operations")
+  }
+
+  b := &clientBuilder{
+    g:        g,
+    doc:      doc,
+    sources:  sources,
+    types:    map[string]*introspection.Type{},
+    declared: map[string]string{},
+    named:    map[string]bool{},
+    data:     &clientData{},
+  }
+  for _, t := range g.types() {
+    b.types[pts(t.Name())] = t
+  }
+  for _, name := range clientTypes {
+    b.declared[name] = "the client transport"
+  }
+  for _, op := range doc.Operations {
+    b.data.Operations = append(b.data.Operations, b.operation(op))
+  }
+
+  g.execute("client.tmpl", b.data)
+  return g.genFile()
+}
+
+// clientBuilder shapes the go types of the operations after their selection sets
+type clientBuilder struct {
+  g       Generator
+  doc     *gqlquery.Document
+  sources sourceMap
+  types   map[string]*introspection.Type
+  // declared holds what declares each go name of the client to report collisions
+  declared map[string]string
+  // named holds the schema types already declared in the client
+  named map[string]bool
+  data  *clientData
+  // op is the operation being generated
+  op *ClientOperation
+}
+
+// declare reserves a go name of the client
+func (b *clientBuilder) declare(name, by string, loc gqlerrors.Location) {
+  if other, exists := b.declared[name]; exists {
+    b.g.Fail(b.sources.position(loc)+":", "go name", name, "of", by, "collides with the one of", other)
+  }
+  b.declared[name] = by
+}
+
+func (b *clientBuilder) operation(def *gqlquery.Operation) *ClientOperation {
+  if def.Name == "" {
+    b.g.Fail(b.sources.position(def.Loc)+":", "anonymous operations are not supported by the client, name the operation")
+  }
+  if def.Kind == "subscription" {
+    b.g.Fail(b.sources.position(def.Loc)+":", "subscription", def.Name, "is not supported by the client, subscriptions are served over websockets")
+  }
+
+  op := &ClientOperation{
+    Name:     upperFirst(def.Name),
+    OpName:   def.Name,
+    Kind:     def.Kind,
+    Document: b.document(def),
+  }
+  b.op = op
+  by := def.Kind + " " + def.Name
+  if clientMethods[op.Name] {
+    b.g.Fail(b.sources.position(def.Loc)+":", by, "collides with the", op.Name, "method of the client")
+  }
+  b.declare("Client."+op.Name, by, def.Loc)
+  b.declare(op.Name+"Document", by, def.Loc)
+
+  if len(def.Vars) > 0 {
+    op.Variables = &ClientStruct{
+      Name:        op.Name + "Variables",
+      Description: "are the variables of the " + def.Name + " " + def.Kind,
+    }
+    b.declare(op.Variables.Name, by, def.Loc)
+    op.Types = append(op.Types, op.Variables)
+    for _, v := range def.Vars {
+      op.Variables.Fields = append(op.Variables.Fields, &ClientField{
+        Name:      fieldName(v.Name),
+        Type:      b.variableType(v.Type),
+        Key:       v.Name,
+        OmitEmpty: !v.Type.NonNull,
+      })
+    }
+  }
+
+  root := b.types[b.g.schema.ASTSchema().EntryPointNames[def.Kind]]
+  op.Result = b.selectionStruct(op.Name+"Result", by, def.Loc, []scopedSelections{{root, def.Selections}})
+  op.Result.Description = "is the result of the " + def.Name + " " + def.Kind
+  return op
+}
+
+// document returns the go literal of the operation followed by the fragments it uses
+func (b *clientBuilder) document(def *gqlquery.Operation) string {
+  texts := []string{def.Text}
+  used := map[string]bool{}
+  var walk func(sels []gqlquery.Selection)
+  walk = func(sels []gqlquery.Selection) {
+    for _, sel := range sels {
+      switch sel := sel.(type) {
+      case *gqlquery.Field:
+        walk(sel.Selections)
+      case *gqlquery.InlineFragment:
+        walk(sel.Selections)
+      case *gqlquery.FragmentSpread:
+        if used[sel.Name] {
+          continue
+        }
+        used[sel.Name] = true
+        frag := b.doc.Fragments[sel.Name]
+        texts = append(texts, frag.Text)
+        walk(frag.Selections)
+      }
+    }
+  }
+  walk(def.Selections)
+
+  doc := strings.Join(texts, "\n\n")
+  if strings.Contains(doc, "`") {
+    return strconv.Quote(doc)
+  }
+  return "`" + doc + "`"
+}
+
+// scopedSelections is a selection set with the type it selects the fields of
+type scopedSelections struct {
+  parent *introspection.Type
+  sels   []gqlquery.Selection
+}
+
+// selectedField is a field of the response merged from all the selections with its response key
+type selectedField struct {
+  key string
+  // typ is nil for __typename
+  typ *introspection.Type
+  // optional is set when the field is only selected by a fragment on a narrower type or with a @skip or @include directive
+  optional bool
+  scopes   []scopedSelections
+  loc      gqlerrors.Location
+}
+
+type selectedFields struct {
+  list  []*selectedField
+  index map[string]*selectedField
+}
+
+// collect merges the fields selected by sels, following the fragments, in the order they are selected
+func (b *clientBuilder) collect(parent *introspection.Type, sels []gqlquery.Selection, optional bool, fields *selectedFields) {
+  for _, sel := range sels {
+    switch sel := sel.(type) {
+    case *gqlquery.Field:
+      opt := optional || sel.Conditional
+      f, exists := fields.index[sel.Key()]
+      if !exists {
+        f = &selectedField{
+          key:      sel.Key(),
+          optional: opt,
+          loc:      sel.Loc,
+        }
+        if sel.Name != "__typename" {
+          f.typ = b.fieldType(parent, sel.Name)
+        }
+        fields.index[f.key] = f
+        fields.list = append(fields.list, f)
+      }
+      f.optional = f.optional && opt
+      if len(sel.Selections) > 0 {
+        f.scopes = append(f.scopes, scopedSelections{namedType(b.fieldType(parent, sel.Name)), sel.Selections})
+      }
+    case *gqlquery.FragmentSpread:
+      frag := b.doc.Fragments[sel.Name]
+      on := b.types[frag.On]
+      b.collect(on, frag.Selections, optional || sel.Conditional || narrows(parent, on), fields)
+    case *gqlquery.InlineFragment:
+      on := parent
+      if sel.On != "" {
+        on = b.types[sel.On]
+      }
+      b.collect(on, sel.Selections, optional || sel.Conditional || narrows(parent, on), fields)
+    }
+  }
+}
+
+// narrows reports whether a fragment on type `on` only applies to some of the values of an abstract parent type
+func narrows(parent, on *introspection.Type) bool {
+  return (parent.Kind() == gqlINTERFACE || parent.Kind() == gqlUNION) && pts(parent.Name()) != pts(on.Name())
+}
+
+// fieldType returns the type of the field of an object or interface type
+func (b *clientBuilder) fieldType(parent *introspection.Type, name string) *introspection.Type {
+  if fields := parent.Fields(&struct{ IncludeDeprecated bool }{true}); fields != nil {
+    for _, f := range *fields {
+      if f.Name() == name {
+        return f.Type()
+      }
+    }
+  }
+  b.g.Fail("unknown field", pts(parent.Name())+"."+name)
+  return nil
+}
+
+// selectionStruct generates the struct of a selection set, the structs of the nested selection sets are named after
+// their path i.e., GetPersonResultPersonFriends
+func (b *clientBuilder) selectionStruct(name, by string, loc gqlerrors.Location, scopes []scopedSelections) *ClientStruct {
+  b.declare(name, by, loc)
+  st := &ClientStruct{Name: name}
+  b.op.Types = append(b.op.Types, st)
+
+  fields := &selectedFields{index: map[string]*selectedField{}}
+  for _, s := range scopes {
+    b.collect(s.parent, s.sels, false, fields)
+  }
+
+  keys := map[string]string{}
+  for _, f := range fields.list {
+    goName := fieldName(strings.TrimLeft(f.key, "_"))
+    if goName == "" {
+      b.g.Fail(b.sources.position(f.loc)+":", "field", f.key, "of", by, "has no go name, alias it")
+    }
+    if other, exists := keys[goName]; exists {
+      b.g.Fail(b.sources.position(f.loc)+":", "fields", other, "and", f.key, "of", by, "map to the same go field", name+"."+goName)
+    }
+    keys[goName] = f.key
+
+    typ := "string"
+    if f.typ != nil {
+      typ = b.goType(f.typ, func(t *introspection.Type) string {
+        switch t.Kind() {
+        case gqlOBJECT, gqlINTERFACE, gqlUNION:
+          return b.selectionStruct(name+goName, by, f.loc, f.scopes).Name
+        }
+        return b.leafType(t)
+      })
+    }
+    if f.optional && !strings.HasPrefix(typ, "*") && !strings.HasPrefix(typ, "[]") {
+      typ = "*" + typ
+    }
+    st.Fields = append(st.Fields, &ClientField{Name: goName, Type: typ, Key: f.key})
+  }
+  return st
+}
+
+// goType returns the go type of a type reference, nullable values are pointers and lists are slices
+func (b *clientBuilder) goType(t *introspection.Type, named func(t *introspection.Type) string) string {
+  switch t.Kind() {
+  case "NON_NULL":
+    return strings.TrimPrefix(b.goType(t.OfType(), named), "*")
+  case gqlLIST:
+    return "[]" + b.goType(t.OfType(), named)
+  }
+  return "*" + named(t)
+}
+
+// variableType returns the go type of the type of a variable
+func (b *clientBuilder) variableType(ref *gqlquery.TypeRef) string {
+  var typ string
+  if ref.Elem != nil {
+    typ = "[]" + b.variableType(ref.Elem)
+  } else {
+    typ = "*" + b.inputType(b.types[ref.Name])
+  }
+  if ref.NonNull {
+    typ = strings.TrimPrefix(typ, "*")
+  }
+  return typ
+}
+
+// inputType returns the go type of an input object, enum or scalar, declaring it in the client if needed
+func (b *clientBuilder) inputType(t *introspection.Type) string {
+  if t.Kind() != gqlINPUT_OBJECT {
+    return b.leafType(t)
+  }
+
+  name := pts(t.Name())
+  if b.named[name] {
+    return name
+  }
+  b.named[name] = true
+  b.declare(name, "input "+name, typeLocation(b.g.schema.ASTSchema().Types[name]))
+
+  st := &ClientStruct{
+    Name:        name,
+    Description: pts(t.Description()),
+  }
+  b.data.Inputs = append(b.data.Inputs, st)
+  for _, input := range *t.InputFields() {
+    st.Fields = append(st.Fields, &ClientField{
+      Name:      fieldName(input.Name()),
+      Type:      b.goType(input.Type(), b.inputType),
+      Key:       input.Name(),
+      OmitEmpty: input.Type().Kind() != "NON_NULL",
+    })
+  }
+  return name
+}
+
+// leafType returns the go type of a scalar or enum type, which is the one of the generated server.
+// The unbound enums and custom scalars are declared in the client, as well as the wrappers of the scalars
+// bound with marshal functions
+func (b *clientBuilder) leafType(t *introspection.Type) string {
+  f := newField("", nil, t)
+  f.Parse(b.g.Bindings)
+
+  name := pts(t.Name())
+  binding := b.g.Bindings[name]
+  wraps := binding != nil && binding.Wraps()
+  if b.named[name] {
+    return name
+  }
+  if KnownGQLTypes[name] || (binding != nil && !wraps) {
+    return f.Type.GoType
+  }
+  b.named[name] = true
+
+  td := NewType(t, b.g.Bindings)
+  by := strings.ToLower(td.Kind) + " " + name
+  loc := typeLocation(b.g.schema.ASTSchema().Types[name])
+  b.declare(name, by, loc)
+  switch td.Kind {
+  case gqlENUM:
+    for _, v := range td.Values {
+      b.declare(td.EnumConst(v), by, loc)
+    }
+    b.data.Enums = append(b.data.Enums, td)
+  case gqlSCALAR:
+    b.data.Scalars = append(b.data.Scalars, td)
+  }
+  if wraps {
+    return name
+  }
+  return f.Type.GoType
+}
+
+// namedType returns the named type of a type reference
+func namedType(t *introspection.Type) *introspection.Type {
+  for t.OfType() != nil {
+    t = t.OfType()
+  }
+  return t
+}
@@ -0,0 +1,140 @@
+package generator
+
+import (
+  "os"
+  "strings"
+  "testing"
+)
+
+const clientSchema = `
+schema {
+  query: Query
+  subscription: Subscription
+}
+
+type Query {
+  team(id: ID!, roles: [Role!]): Team
+  search(filter: Filter!): [Result]!
+}
+
+type Subscription {
+  joined: Member!
+}
+
+enum Role {
+  ADMIN
+  USER
+}
+
+input Filter {
+  text: String!
+  role: Role
+}
+
+type Team {
+  id: ID!
+  name: String
+  members: [Member!]!
+}
+
+type Member {
+  name: String!
+  role: Role!
+}
+
+union Result = Team | Member
+`
+
+// genClient generates the client of the operations for the clientSchema
+func genClient(t *testing.T, operations string) string {
+  g := newTestGenerator(t, clientSchema)
+  return string(g.GenClientFile([]*SchemaFile{{Name: "operations.graphql", Content: []byte(operations)}}))
+}
+
+func TestGenClientFile(t *testing.T) {
+  src := genClient(t, `
+query getTeam($id: ID!, $roles: [Role!]) {
+  team(id: $id, roles: $roles) {
+    ...TeamFields
+    members { name role }
+  }
+}
+
+query search($filter: Filter!) {
+  search(filter: $filter) {
+    ... on Team { teamName: name }
+    ... on Member { name }
+  }
+}
+
+fragment TeamFields on Team {
+  id
+  name
+}
+`)
+
+  for _, want := range []string{
+    "func (c *Client) GetTeam(ctx context.Context, vars GetTeamVariables) (*GetTeamResult, error)",
+    `err := c.Do(ctx, GetTeamDocument, "getTeam", vars, &result)`,
+    "fragment TeamFields on Team {\n  id\n  name\n}`",
+    "type GetTeamVariables struct {\n\tID    string `json:\"id\"`\n\tRoles []Role `json:\"roles,omitempty\"`\n}",
+    "type GetTeamResult struct {\n\tTeam *GetTeamResultTeam `json:\"team\"`\n}",
+    "\tName    *string                    `json:\"name\"`",
+    "\tMembers []GetTeamResultTeamMembers `json:\"members\"`",
+    "type GetTeamResultTeamMembers struct {\n\tName string `json:\"name\"`\n\tRole Role   `json:\"role\"`\n}",
+    "type Filter struct {\n\tText string `json:\"text\"`\n\tRole *Role  `json:\"role,omitempty\"`\n}",
+    "type SearchResultSearch struct {\n\tTeamName *string `json:\"teamName\"`\n\tName     *string `json:\"name\"`\n}",
+    "type Role string",
+  } {
+    if !strings.Contains(src, want) {
+      t.Errorf("generated client misses\n%s", want)
+    }
+  }
+  // the fragments are only sent with the operations using them
+  if doc := src[strings.Index(src, "const SearchDocument"):]; strings.Contains(doc[:strings.Index(doc, "`\n")], "TeamFields") {
+    t.Error("the search query is sent with the TeamFields fragment it does not use")
+  }
+}
+
+func TestGenClientFileErrors(t *testing.T) {
+  if operations := os.Getenv("CLIENT_OPERATIONS"); operations != "" {
+    genClient(t, operations)
+    return
+  }
+
+  for name, test := range map[string]struct {
+    operations string
+    want       string
+  }{
+    "invalid operation": {
+      operations: "query a {\n  team(id: 1) {\n    size\n  }\n}\n",
+      want:       `operations.graphql:3:5: Cannot query field "size" on type "Team".`,
+    },
+    "anonymous operation": {
+      operations: "\n{ team(id: 1) { id } }\n",
+      want:       "operations.graphql:2:1: anonymous operations are not supported by the client",
+    },
+    "subscription": {
+      operations: "subscription onJoin { joined { name } }\n",
+      want:       "subscription onJoin is not supported by the client",
+    },
+    "client method": {
+      operations: "query do { team(id: 1) { id } }\n",
+      want:       "query do collides with the Do method of the client",
+    },
+    "go names": {
+      operations: "query team { team(id: 1) { id } }\nquery Team { team(id: 2) { id } }\n",
+      want:       "operations.graphql:2:1: go name Client.Team of query Team collides with the one of query team",
+    },
+    "fields": {
+      operations: "query a { team(id: 1) { id iD: name } }\n",
+      want:       "fields id and iD of query a map to the same go field AResultTeam.ID",
+    },
+  } {
+    t.Run(name, func(t *testing.T) {
+      if out := runFailing(t, "TestGenClientFileErrors", "CLIENT_OPERATIONS="+test.operations); !strings.Contains(out, test.want) {
+        t.Errorf("got %q, want it to contain %q", out, test.want)
+      }
+    })
+  }
+}
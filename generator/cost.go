@@ -0,0 +1,158 @@
+package generator
+
+import (
+  gqltypes "github.com/graph-gophers/graphql-go/types"
+)
+
+// CostDirective is the directive of the schema setting the cost of a field, i.e.,
+// `friends(first: Int): [Person] @cost(weight: 2, multipliers: ["first"])`
+const CostDirective = "cost"
+
+// costType holds the fields of an object or interface type which count in the cost of an operation
+type costType struct {
+  Name   string
+  Fields []*costField
+}
+
+type costField struct {
+  Name string
+  // Type is the named type returned by the field
+  Type string
+  // Weight is 1 for the fields returning an object, interface or union and 0 for the others unless set with @cost,
+  // whose weight argument defaults to its default value in the declaration of the directive
+  Weight int
+  // Multipliers are the arguments multiplying the cost of the field
+  Multipliers []*costMultiplier
+}
+
+type costMultiplier struct {
+  Arg string
+  // Default is the default value of the argument, or the length of its default list, which counts
+  // when the argument is omitted or is not positive
+  Default int
+}
+
+// costTypes returns the fields of the types which count in the cost of an operation,
+// the fields of scalars and enums without @cost being free
+func (g Generator) costTypes() []*costType {
+  ast := g.schema.ASTSchema()
+  var types []*costType
+  for _, t := range g.types() {
+    var fields gqltypes.FieldsDefinition
+    switch def := ast.Types[pts(t.Name())].(type) {
+    case *gqltypes.ObjectTypeDefinition:
+      fields = def.Fields
+    case *gqltypes.InterfaceTypeDefinition:
+      fields = def.Fields
+    default:
+      continue
+    }
+    if KnownGQLTypes[pts(t.Name())] {
+      continue
+    }
+
+    ct := &costType{Name: pts(t.Name())}
+    for _, f := range fields {
+      cf := g.costField(ct.Name, f)
+      if cf.Weight > 0 || len(cf.Multipliers) > 0 || cf.Type != "" {
+        ct.Fields = append(ct.Fields, cf)
+      }
+    }
+    if len(ct.Fields) > 0 {
+      types = append(types, ct)
+    }
+  }
+  return types
+}
+
+// costField returns the cost of a field, its type is only set when the field has a selection set
+func (g Generator) costField(parent string, f *gqltypes.FieldDefinition) *costField {
+  cf := &costField{Name: f.Name}
+  switch named := namedASTType(f.Type).(type) {
+  case *gqltypes.ObjectTypeDefinition, *gqltypes.InterfaceTypeDefinition, *gqltypes.Union:
+    cf.Type = named.(gqltypes.NamedType).TypeName()
+    cf.Weight = 1
+  }
+
+  d := f.Directives.Get(CostDirective)
+  if d == nil {
+    return cf
+  }
+  position := g.sources.position(f.Loc) + ":"
+  if arg := g.costWeight(d); arg != nil {
+    weight, ok := intValue(arg.Deserialize(nil))
+    if !ok || weight < 0 {
+      g.Fail(position, "the weight of @cost of", parent+"."+f.Name, "must be a non-negative integer")
+    }
+    cf.Weight = weight
+  }
+  // graphql-go adds the omitted arguments with their default value, nil when they have none
+  if arg, ok := d.Arguments.Get("multipliers"); ok && arg != nil {
+    names, _ := arg.Deserialize(nil).([]interface{})
+    for _, v := range names {
+      name, _ := v.(string)
+      def := f.Arguments.Get(name)
+      if def == nil {
+        g.Fail(position, "multiplier", name, "of @cost of", parent+"."+f.Name, "is not an argument of the field")
+      }
+      n, ok := multiplierDefault(def.Default)
+      if !ok {
+        g.Fail(position, "multiplier", name, "of @cost of", parent+"."+f.Name,
+          "must have a default value which is a positive integer or a non-empty list")
+      }
+      cf.Multipliers = append(cf.Multipliers, &costMultiplier{Arg: def.Name.Name, Default: n})
+    }
+  }
+  return cf
+}
+
+// costWeight returns the weight argument of a @cost directive, or the default value of the argument in the declaration
+// of the directive when it is omitted, or nil when the declaration has none
+func (g Generator) costWeight(d *gqltypes.Directive) gqltypes.Value {
+  if arg, ok := d.Arguments.Get("weight"); ok && arg != nil {
+    return arg
+  }
+  if decl := g.schema.ASTSchema().Directives[CostDirective]; decl != nil {
+    if def := decl.Arguments.Get("weight"); def != nil {
+      return def.Default
+    }
+  }
+  return nil
+}
+
+// namedASTType returns the named type of a type reference of the schema
+func namedASTType(t gqltypes.Type) gqltypes.Type {
+  for {
+    switch w := t.(type) {
+    case *gqltypes.NonNull:
+      t = w.OfType
+    case *gqltypes.List:
+      t = w.OfType
+    default:
+      return t
+    }
+  }
+}
+
+// multiplierDefault returns the count of the default value of a multiplier argument, which must be positive
+func multiplierDefault(def gqltypes.Value) (int, bool) {
+  if def == nil {
+    return 0, false
+  }
+  v := def.Deserialize(nil)
+  n, ok := intValue(v)
+  if list, isList := v.([]interface{}); isList {
+    n, ok = len(list), true
+  }
+  return n, ok && n > 0
+}
+
+func intValue(v interface{}) (int, bool) {
+  switch v := v.(type) {
+  case int32:
+    return int(v), true
+  case int:
+    return v, true
+  }
+  return 0, false
+}
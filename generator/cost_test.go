@@ -0,0 +1,115 @@
+package generator
+
+import (
+  "os"
+  "reflect"
+  "strings"
+  "testing"
+)
+
+// costsOf returns the cost fields of the types of the schema by type and field name
+func costsOf(t *testing.T, schema string) map[string]map[string]costField {
+  g := New()
+  if err := g.Parse([]byte(schema)); err != nil {
+    t.Fatal(err)
+  }
+  costs := map[string]map[string]costField{}
+  for _, ct := range g.costTypes() {
+    costs[ct.Name] = map[string]costField{}
+    for _, f := range ct.Fields {
+      costs[ct.Name][f.Name] = *f
+    }
+  }
+  return costs
+}
+
+func TestCostTypes(t *testing.T) {
+  costs := costsOf(t, `
+directive @cost(weight: Int = 3, multipliers: [String!]) on FIELD_DEFINITION
+
+type Query {
+  person(id: ID!): Person
+  count: Int
+  score: Float @cost(weight: 5)
+  rank: Int @cost
+  people(first: Int = 10, last: Int = 5): [Person] @cost(weight: 2, multipliers: ["first", "last"])
+}
+
+type Person {
+  id: ID!
+  friends(first: Int = 20, ids: [ID!] = ["1", "2"]): [Person] @cost(multipliers: ["first", "ids"])
+}
+`)
+  want := map[string]map[string]costField{
+    "Query": {
+      "person": {Name: "person", Type: "Person", Weight: 1},
+      "score":  {Name: "score", Weight: 5},
+      "rank":   {Name: "rank", Weight: 3},
+      "people": {Name: "people", Type: "Person", Weight: 2, Multipliers: []*costMultiplier{{Arg: "first", Default: 10}, {Arg: "last", Default: 5}}},
+    },
+    "Person": {
+      "friends": {Name: "friends", Type: "Person", Weight: 3, Multipliers: []*costMultiplier{{Arg: "first", Default: 20}, {Arg: "ids", Default: 2}}},
+    },
+  }
+  if !reflect.DeepEqual(costs, want) {
+    t.Errorf("the costs are %+v, want %+v", costs, want)
+  }
+}
+
+func TestCostWithoutDefaultWeight(t *testing.T) {
+  costs := costsOf(t, `
+directive @cost(weight: Int, multipliers: [String!]) on FIELD_DEFINITION
+
+type Query {
+  people(first: Int = 10): [Person] @cost(multipliers: ["first"])
+  count: Int @cost(multipliers: [])
+}
+
+type Person {
+  id: ID!
+}
+`)
+  if f := costs["Query"]["people"]; f.Weight != 1 || len(f.Multipliers) != 1 {
+    t.Errorf("people is %+v, want the weight of an object field", f)
+  }
+  if f, ok := costs["Query"]["count"]; ok {
+    t.Errorf("count is %+v, want it free", f)
+  }
+}
+
+func TestCostMultiplierErrors(t *testing.T) {
+  if schema := os.Getenv("COST_SCHEMA"); schema != "" {
+    costsOf(t, schema)
+    return
+  }
+
+  for name, test := range map[string]struct {
+    args string
+    want string
+  }{
+    "unknown argument": {
+      args: "first: Int = 10",
+      want: "5:3: multiplier last of @cost of Query.people is not an argument of the field",
+    },
+    "no default": {
+      args: "last: Int",
+      want: "5:3: multiplier last of @cost of Query.people must have a default value which is a positive integer or a non-empty list",
+    },
+    "zero default": {
+      args: "last: Int = 0",
+      want: "multiplier last of @cost of Query.people must have a default value",
+    },
+    "empty list default": {
+      args: "last: [ID!] = []",
+      want: "multiplier last of @cost of Query.people must have a default value",
+    },
+  } {
+    t.Run(name, func(t *testing.T) {
+      schema := "directive @cost(weight: Int = 1, multipliers: [String!]) on FIELD_DEFINITION\n\ntype Query {\n  count: Int\n" +
+        "  people(" + test.args + "): [Int] @cost(multipliers: [\"last\"])\n}\n"
+      if out := runFailing(t, "TestCostMultiplierErrors", "COST_SCHEMA="+schema); !strings.Contains(out, test.want) {
+        t.Errorf("got %q, want it to contain %q", out, test.want)
+      }
+    })
+  }
+}
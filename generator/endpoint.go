@@ -0,0 +1,73 @@
+package generator
+
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "io/ioutil"
+  "net/http"
+  "strings"
+  "time"
+)
+
+// EndpointTimeout is the timeout of the introspection request sent by FetchSchema
+var EndpointTimeout = 30 * time.Second
+
+// ParseHeaders parses http headers like `Authorization: Bearer TOKEN`
+func ParseHeaders(defs []string) (http.Header, error) {
+  headers := http.Header{}
+  for _, def := range defs {
+    kv := strings.SplitN(def, ":", 2)
+    if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+      return nil, fmt.Errorf("invalid header %q, expected NAME: VALUE", def)
+    }
+    headers.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+  }
+  return headers, nil
+}
+
+// FetchSchema sends the IntrospectionQuery to a running GraphQL endpoint with the given headers
+// and returns its schema in SDL as a schema file named after the url
+func FetchSchema(url string, headers http.Header) (*SchemaFile, error) {
+  body, err := json.Marshal(map[string]interface{}{
+    "query":         IntrospectionQuery,
+    "operationName": "IntrospectionQuery",
+  })
+  if err != nil {
+    return nil, err
+  }
+
+  req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+  if err != nil {
+    return nil, err
+  }
+  for name, values := range headers {
+    req.Header[name] = values
+  }
+  req.Header.Set("Content-Type", "application/json")
+  req.Header.Set("Accept", "application/json")
+
+  client := &http.Client{Timeout: EndpointTimeout}
+  res, err := client.Do(req)
+  if err != nil {
+    return nil, err
+  }
+  defer res.Body.Close()
+
+  data, err := ioutil.ReadAll(res.Body)
+  if err != nil {
+    return nil, err
+  }
+  if res.StatusCode != http.StatusOK {
+    return nil, fmt.Errorf("introspection of %s failed with status %s: %s", url, res.Status, bytes.TrimSpace(data))
+  }
+
+  sdl, err := IntrospectionToSDL(data)
+  if err != nil {
+    return nil, fmt.Errorf("%s: %v", url, err)
+  }
+  return &SchemaFile{
+    Name:    url,
+    Content: sdl,
+  }, nil
+}
@@ -0,0 +1,146 @@
+package generator
+
+import (
+  "encoding/json"
+  "net/http"
+  "net/http/httptest"
+  "strings"
+  "testing"
+
+  graphql "github.com/graph-gophers/graphql-go"
+)
+
+const endpointSchema = `
+schema {
+  query: Query
+}
+
+"A person of the catalog"
+type Person {
+  id: ID!
+  name: String!
+  friends(first: Int = 10): [Person!]!
+  role: Role @deprecated(reason: "use roles")
+}
+
+enum Role {
+  ADMIN
+  USER
+}
+
+input PersonFilter {
+  name: String
+  roles: [Role!]
+}
+
+type Query {
+  people(filter: PersonFilter): [Person!]!
+}
+`
+
+type endpointResolver struct{}
+
+type endpointPerson struct{}
+
+type endpointFilter struct {
+  Name  *string
+  Roles *[]string
+}
+
+func (*endpointResolver) People(args struct{ Filter *endpointFilter }) []endpointPerson {
+  return nil
+}
+
+func (endpointPerson) ID() graphql.ID { return "" }
+
+func (endpointPerson) Name() string { return "" }
+
+func (endpointPerson) Friends(args struct{ First int32 }) []endpointPerson { return nil }
+
+func (endpointPerson) Role() *string { return nil }
+
+// newEndpoint serves the introspection of the schema to the requests with the token
+func newEndpoint(t *testing.T, token string, opts ...graphql.SchemaOpt) *httptest.Server {
+  schema := graphql.MustParseSchema(endpointSchema, &endpointResolver{}, append(opts, graphql.UseStringDescriptions())...)
+  srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+    if r.Header.Get("Authorization") != "Bearer "+token {
+      http.Error(w, "unauthorized", http.StatusUnauthorized)
+      return
+    }
+    var req struct {
+      Query         string                 `json:"query"`
+      OperationName string                 `json:"operationName"`
+      Variables     map[string]interface{} `json:"variables"`
+    }
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+      http.Error(w, err.Error(), http.StatusBadRequest)
+      return
+    }
+    json.NewEncoder(w).Encode(schema.Exec(r.Context(), req.Query, req.OperationName, req.Variables))
+  }))
+  t.Cleanup(srv.Close)
+  return srv
+}
+
+func TestFetchSchema(t *testing.T) {
+  srv := newEndpoint(t, "secret")
+  headers, err := ParseHeaders([]string{"Authorization: Bearer secret"})
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  file, err := FetchSchema(srv.URL, headers)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if file.Name != srv.URL {
+    t.Errorf("the schema file is named %q, want the url", file.Name)
+  }
+
+  sdl := string(file.Content)
+  for _, want := range []string{
+    "# A person of the catalog\ntype Person {",
+    "friends(first: Int = 10): [Person!]!",
+    `role: Role @deprecated(reason: "use roles")`,
+    "input PersonFilter {",
+    "roles: [Role!]",
+    "people(filter: PersonFilter): [Person!]!",
+  } {
+    if !strings.Contains(sdl, want) {
+      t.Errorf("the schema lacks %q:\n%s", want, sdl)
+    }
+  }
+
+  // the fetched schema is generated from like a local one
+  g := New()
+  if err := g.ParseFiles([]*SchemaFile{file}); err != nil {
+    t.Fatalf("the fetched schema does not parse: %v\n%s", err, sdl)
+  }
+  names := map[string]bool{}
+  for _, typ := range g.types() {
+    names[pts(typ.Name())] = true
+  }
+  for _, name := range []string{"Query", "Person", "Role", "PersonFilter"} {
+    if !names[name] {
+      t.Errorf("the schema lacks the type %s", name)
+    }
+  }
+}
+
+func TestFetchSchemaErrors(t *testing.T) {
+  srv := newEndpoint(t, "secret")
+  if _, err := FetchSchema(srv.URL, nil); err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+    t.Errorf("fetching without the token returned %v, want the status", err)
+  }
+
+  // the IntrospectionQuery is 13 levels deep
+  shallow := newEndpoint(t, "secret", graphql.MaxDepth(10))
+  headers := http.Header{"Authorization": {"Bearer secret"}}
+  if _, err := FetchSchema(shallow.URL, headers); err == nil || !strings.Contains(err.Error(), "introspection failed") {
+    t.Errorf("fetching from an endpoint refusing the query returned %v, want its errors", err)
+  }
+
+  if _, err := ParseHeaders([]string{"Authorization"}); err == nil {
+    t.Error("a header without a value is accepted")
+  }
+}
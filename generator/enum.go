@@ -0,0 +1,28 @@
+package generator
+
+import (
+  "strings"
+
+  "github.com/graph-gophers/graphql-go/introspection"
+)
+
+// enumValues returns the values of an enum type including the deprecated ones
+func enumValues(t *introspection.Type) []string {
+  var values []string
+  for _, v := range *t.EnumValues(&struct{ IncludeDeprecated bool }{true}) {
+    values = append(values, v.Name())
+  }
+  return values
+}
+
+// enumConstName converts an enum value to the name of its go constant, i.e., NEW_HOPE -> NewHope
+func enumConstName(value string) string {
+  name := ""
+  for _, part := range strings.Split(value, "_") {
+    if part == strings.ToUpper(part) {
+      part = strings.ToLower(part)
+    }
+    name += upperFirst(part)
+  }
+  return name
+}
@@ -0,0 +1,142 @@
+package generator
+
+import (
+  "strings"
+  "testing"
+)
+
+const enumSchema = `
+schema {
+  query: Query
+}
+
+enum Episode {
+  NEW_HOPE
+  EMPIRE
+  JEDI @deprecated
+}
+
+type Query {
+  hero(episode: Episode, episodes: [Episode!]): Movie
+}
+
+type Movie {
+  id: ID!
+  episode: Episode!
+  optional: Episode
+  episodes: [Episode!]!
+  optionalEpisodes: [Episode]
+  next(after: Episode!): Episode
+}
+
+input Filter {
+  episode: Episode
+  episodes: [Episode]
+}
+`
+
+func TestEnumConstName(t *testing.T) {
+  for value, want := range map[string]string{
+    "JEDI":           "Jedi",
+    "NEW_HOPE":       "NewHope",
+    "newHope":        "NewHope",
+    "EMPIRE_strikes": "EmpireStrikes",
+  } {
+    if got := enumConstName(value); got != want {
+      t.Errorf("got %s for %s, want %s", got, value, want)
+    }
+  }
+}
+
+func TestEnumBindings(t *testing.T) {
+  for name, test := range map[string]struct {
+    binding  string
+    files    map[string]string
+    want     []string
+    unwanted []string
+  }{
+    "unbound": {
+      want: []string{
+        "type Episode string",
+        "\tEpisodeNewHope Episode = \"NEW_HOPE\"\n",
+        // the deprecated values are still valid
+        "\tEpisodeJedi    Episode = \"JEDI\"\n",
+        "case EpisodeNewHope, EpisodeEmpire, EpisodeJedi:",
+        "func (e *Episode) UnmarshalGraphQL(input interface{}) error {",
+        // a nullable enum is a pointer in the model which resolves to null when it is nil
+        "\tOptional         *Episode\n",
+        "\tOptionalEpisodes []*Episode\n",
+        "func (r MovieResolver) Optional(ctx context.Context) (*Episode, error) {\n\treturn r.R.Optional, nil\n}",
+        // the items are returned as they are instead of the address of the loop variable
+        "for _, itm := range r.R.OptionalEpisodes {\n\t\titems = append(items, itm)\n\t}",
+        "type QueryHeroArgs struct {\n\tEpisode  *Episode\n\tEpisodes *[]Episode\n}",
+        "type Filter struct {\n\tEpisode  *Episode\n\tEpisodes *[]*Episode\n}",
+      },
+      unwanted: []string{"IsValidEpisode", "&itm"},
+    },
+    "bound with a prefix": {
+      binding: "Episode=Ep,prefix=Ep",
+      files: map[string]string{"episode.go": `package api
+
+type Ep string
+
+const (
+  EpNewHope Ep = "NEW_HOPE"
+  EpEmpire  Ep = "EMPIRE"
+  EpJedi    Ep = "JEDI"
+)
+`},
+      want: []string{
+        "var validEpisode = map[Ep]bool{\n\tEpNewHope: true,\n\tEpEmpire:  true,\n\tEpJedi:    true,\n}",
+        "func IsValidEpisode(v Ep) bool {\n\treturn validEpisode[v]\n}",
+        "\tOptional         *Ep\n",
+        "func (r MovieResolver) Optional(ctx context.Context) (*Ep, error) {\n\treturn r.R.Optional, nil\n}",
+        "type MovieNextArgs struct {\n\tAfter Ep\n}",
+      },
+      unwanted: []string{"type Episode ", "&itm"},
+    },
+    "bound to the constants named after the type": {
+      binding: "Episode=Season",
+      files: map[string]string{"season.go": `package api
+
+type Season string
+
+const (
+  SeasonNewHope Season = "NEW_HOPE"
+  SeasonEmpire  Season = "EMPIRE"
+  SeasonJedi    Season = "JEDI"
+)
+`},
+      want: []string{
+        "var validEpisode = map[Season]bool{\n\tSeasonNewHope: true,",
+        "func IsValidEpisode(v Season) bool {",
+        "\tOptionalEpisodes []*Season\n",
+      },
+      unwanted: []string{"type Episode "},
+    },
+  } {
+    t.Run(name, func(t *testing.T) {
+      g := newTestGenerator(t, enumSchema)
+      if test.binding != "" {
+        bindings, err := ParseBindings([]string{test.binding})
+        if err != nil {
+          t.Fatal(err)
+        }
+        g.SetBindings(bindings)
+      }
+
+      src := string(g.GenSchemaResolversFile())
+      for _, want := range test.want {
+        if !strings.Contains(src, want) {
+          t.Errorf("generated resolvers miss\n%s", want)
+        }
+      }
+      for _, unwanted := range test.unwanted {
+        if strings.Contains(src, unwanted) {
+          t.Errorf("generated resolvers have %q", unwanted)
+        }
+      }
+      compileGenerated(t, g, test.files)
+    })
+  }
+}
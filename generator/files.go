@@ -0,0 +1,173 @@
+package generator
+
+import (
+  "bufio"
+  "bytes"
+  "fmt"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "regexp"
+  "sort"
+  "strings"
+)
+
+// SchemaExtensions are the extensions of the schema files searched for in directories
+var SchemaExtensions = []string{".graphql", ".graphqls", ".gql"}
+
+// SchemaFile is a schema file read by LoadSchema
+type SchemaFile struct {
+  Name    string
+  Content []byte
+}
+
+// importDirective matches the `#import "./common.graphql"` lines including other schema files.
+// As they are comments for graphql, they can be left in the schema
+var importDirective = regexp.MustCompile(`^#import\s+["']([^"']+)["']`)
+
+// schemaLoader reads each schema file once, the files included by a file before the file itself
+type schemaLoader struct {
+  // kind is the kind of the files in the errors, schema or operation
+  kind  string
+  files []*SchemaFile
+  seen  map[string]bool
+}
+
+// LoadSchema reads the schema files of the inputs, which can be files, directories searched recursively
+// for files with one of the SchemaExtensions, or glob patterns i.e., `schema/*/*.graphql`.
+// The files included by a file with `#import "path"` are read too, the path being relative to the file.
+// A file can also hold the JSON result of the IntrospectionQuery, it is converted to SDL.
+// Type extensions (`extend type Query`) are merged by graphql-go when the files are parsed as one schema
+func LoadSchema(inputs []string) ([]*SchemaFile, error) {
+  return (&schemaLoader{kind: "schema"}).loadAll(inputs)
+}
+
+// LoadOperations reads the files of the operations and fragments of a client like LoadSchema does,
+// the files are executable documents so none of them is converted
+func LoadOperations(inputs []string) ([]*SchemaFile, error) {
+  return (&schemaLoader{kind: "operation"}).loadAll(inputs)
+}
+
+func (l *schemaLoader) loadAll(inputs []string) ([]*SchemaFile, error) {
+  if len(inputs) == 0 {
+    return nil, fmt.Errorf("no %s file given", l.kind)
+  }
+
+  l.seen = map[string]bool{}
+  for _, input := range inputs {
+    if err := l.load(input); err != nil {
+      return nil, err
+    }
+  }
+  if len(l.files) == 0 {
+    return nil, fmt.Errorf("no %s file found in %s", l.kind, strings.Join(inputs, ", "))
+  }
+  return l.files, nil
+}
+
+// load reads the files of an input or of an include
+func (l *schemaLoader) load(input string) error {
+  info, err := os.Stat(input)
+  switch {
+  case err == nil && info.IsDir():
+    return l.loadDir(input)
+  case err == nil:
+    return l.loadFile(input)
+  case os.IsNotExist(err) && strings.ContainsAny(input, "*?["):
+    matches, err := filepath.Glob(input)
+    if err != nil {
+      return fmt.Errorf("invalid pattern %s: %v", input, err)
+    }
+    if len(matches) == 0 {
+      return fmt.Errorf("no %s file matches %s", l.kind, input)
+    }
+    for _, match := range matches {
+      if err := l.load(match); err != nil {
+        return err
+      }
+    }
+    return nil
+  default:
+    return err
+  }
+}
+
+func (l *schemaLoader) loadDir(dir string) error {
+  var files []string
+  err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+    if err != nil {
+      return err
+    }
+    if !info.IsDir() && isSchemaFile(path) {
+      files = append(files, path)
+    }
+    return nil
+  })
+  if err != nil {
+    return err
+  }
+  sort.Strings(files)
+
+  for _, file := range files {
+    if err := l.loadFile(file); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+func (l *schemaLoader) loadFile(file string) error {
+  abs, err := filepath.Abs(file)
+  if err != nil {
+    return err
+  }
+  if l.seen[abs] {
+    return nil
+  }
+  // mark the file before its includes are loaded to break include cycles
+  l.seen[abs] = true
+
+  content, err := ioutil.ReadFile(file)
+  if err != nil {
+    return err
+  }
+  // a schema.json with the introspection result is loaded as the SDL it describes
+  if l.kind == "schema" && isIntrospectionJSON(content) {
+    if content, err = IntrospectionToSDL(content); err != nil {
+      return fmt.Errorf("%s: %v", file, err)
+    }
+  }
+
+  s := bufio.NewScanner(bytes.NewReader(content))
+  for line := 1; s.Scan(); line++ {
+    m := importDirective.FindSubmatch(bytes.TrimSpace(s.Bytes()))
+    if m == nil {
+      continue
+    }
+    include := string(m[1])
+    if !filepath.IsAbs(include) {
+      include = filepath.Join(filepath.Dir(file), include)
+    }
+    if err := l.load(include); err != nil {
+      return fmt.Errorf("%s:%d: unable to import %s: %v", file, line, m[1], err)
+    }
+  }
+  if err := s.Err(); err != nil {
+    return err
+  }
+
+  l.files = append(l.files, &SchemaFile{
+    Name:    file,
+    Content: content,
+  })
+  return nil
+}
+
+func isSchemaFile(path string) bool {
+  for _, ext := range SchemaExtensions {
+    if strings.HasSuffix(path, ext) {
+      return true
+    }
+  }
+  return false
+}
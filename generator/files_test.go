@@ -0,0 +1,151 @@
+package generator
+
+import (
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "reflect"
+  "strings"
+  "testing"
+)
+
+// writeFiles writes the files of contents by their path relative to dir
+func writeFiles(t *testing.T, dir string, contents map[string]string) {
+  for name, content := range contents {
+    path := filepath.Join(dir, name)
+    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+      t.Fatal(err)
+    }
+    if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+      t.Fatal(err)
+    }
+  }
+}
+
+// fileNames returns the names of the files relative to dir
+func fileNames(t *testing.T, dir string, files []*SchemaFile) []string {
+  var names []string
+  for _, f := range files {
+    name, err := filepath.Rel(dir, f.Name)
+    if err != nil {
+      t.Fatal(err)
+    }
+    names = append(names, filepath.ToSlash(name))
+  }
+  return names
+}
+
+func TestLoadSchema(t *testing.T) {
+  dir := t.TempDir()
+  writeFiles(t, dir, map[string]string{
+    "schema.graphql":        "schema { query: Query }\ntype Query { person: Person }\n",
+    "types/person.graphqls": "#import \"../common/node.gql\"\ntype Person implements Node { id: ID! }\n",
+    "types/search.graphql":  "extend type Query { search(text: String!): [Person!]! }\n",
+    "common/node.gql":       "interface Node { id: ID! }\n",
+    "common/ignored.txt":    "not a schema",
+    "cycle/a.graphql":       "#import \"./b.graphql\"\ntype A { b: B }\n",
+    "cycle/b.graphql":       "  #import './a.graphql'\ntype B { a: A }\n",
+    "extensions/x.graphql":  "extend type Query { x: Int }\n",
+    "extensions/y.graphql":  "extend type Query { y: Int }\n",
+  })
+  in := func(names ...string) []string {
+    for i, name := range names {
+      names[i] = filepath.Join(dir, name)
+    }
+    return names
+  }
+
+  for name, test := range map[string]struct {
+    inputs []string
+    want   []string
+  }{
+    "file": {
+      inputs: in("schema.graphql"),
+      want:   []string{"schema.graphql"},
+    },
+    "directory": {
+      inputs: in("types"),
+      want:   []string{"common/node.gql", "types/person.graphqls", "types/search.graphql"},
+    },
+    "glob": {
+      inputs: in("extensions/*.graphql"),
+      want:   []string{"extensions/x.graphql", "extensions/y.graphql"},
+    },
+    "import cycle": {
+      inputs: in("cycle/a.graphql"),
+      want:   []string{"cycle/b.graphql", "cycle/a.graphql"},
+    },
+    "files read once": {
+      inputs: in("common", "schema.graphql", "types", "schema.graphql"),
+      want:   []string{"common/node.gql", "schema.graphql", "types/person.graphqls", "types/search.graphql"},
+    },
+  } {
+    t.Run(name, func(t *testing.T) {
+      files, err := LoadSchema(test.inputs)
+      if err != nil {
+        t.Fatal(err)
+      }
+      if got := fileNames(t, dir, files); !reflect.DeepEqual(got, test.want) {
+        t.Errorf("got the files %v, want %v", got, test.want)
+      }
+    })
+  }
+
+  t.Run("type extensions", func(t *testing.T) {
+    files, err := LoadSchema(in("schema.graphql", "types", "extensions"))
+    if err != nil {
+      t.Fatal(err)
+    }
+    g := New()
+    if err := g.ParseFiles(files); err != nil {
+      t.Fatal(err)
+    }
+    var fields []string
+    for _, f := range *g.schema.Inspect().QueryType().Fields(nil) {
+      fields = append(fields, f.Name())
+    }
+    if want := []string{"person", "search", "x", "y"}; !reflect.DeepEqual(fields, want) {
+      t.Errorf("got the fields of Query %v, want %v", fields, want)
+    }
+  })
+}
+
+func TestLoadSchemaErrors(t *testing.T) {
+  dir := t.TempDir()
+  writeFiles(t, dir, map[string]string{
+    "schema.graphql": "type Query { a: Int }\n\n#import \"./missing.graphql\"\n",
+    "docs/notes.txt": "not a schema",
+  })
+
+  for name, test := range map[string]struct {
+    inputs []string
+    want   string
+  }{
+    "no input": {
+      want: "no schema file given",
+    },
+    "missing file": {
+      inputs: []string{filepath.Join(dir, "other.graphql")},
+      want:   "no such file or directory",
+    },
+    "no match": {
+      inputs: []string{filepath.Join(dir, "*.gql")},
+      want:   "no schema file matches " + filepath.Join(dir, "*.gql"),
+    },
+    "no schema file": {
+      inputs: []string{filepath.Join(dir, "docs")},
+      want:   "no schema file found in",
+    },
+    "missing import": {
+      inputs: []string{filepath.Join(dir, "schema.graphql")},
+      want:   filepath.Join(dir, "schema.graphql") + ":3: unable to import ./missing.graphql",
+    },
+  } {
+    t.Run(name, func(t *testing.T) {
+      _, err := LoadSchema(test.inputs)
+      if err == nil || !strings.Contains(err.Error(), test.want) {
+        t.Errorf("got the error %v, want it to contain %q", err, test.want)
+      }
+    })
+  }
+}
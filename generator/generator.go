@@ -1,15 +1,20 @@
 package generator
 
 import (
+  "bufio"
   "bytes"
   "fmt"
+  "go/format"
   "log"
   "os"
+  "sort"
   "strings"
   "unicode"
 
   "github.com/graph-gophers/graphql-go"
+  gqlerrors "github.com/graph-gophers/graphql-go/errors"
   "github.com/graph-gophers/graphql-go/introspection"
+  gqltypes "github.com/graph-gophers/graphql-go/types"
 )
 
 const (
@@ -26,6 +31,43 @@ const (
   gqlMutation     = "Mutation"
 )
 
+// RootResolvers are the names of the resolver interfaces generated for the operation types of the schema
+var RootResolvers = []struct {
+  Operation string
+  Name      string
+}{
+  {"query", "QueryResolver"},
+  {"mutation", "MutationResolver"},
+  {"subscription", "SubscriptionResolver"},
+}
+
+// generatedNames are the go names declared by the generated resolvers and server files whatever the schema,
+// which the go names of the types of the schema must not collide with
+var generatedNames = map[string]bool{
+  // resolvers file
+  "GqlResolver": true, "QueryResolver": true, "MutationResolver": true, "SubscriptionResolver": true, "Schema": true,
+  "LoaderConfig": true, "DefaultLoaderConfig": true, "Fetchers": true, "Loaders": true, "NewLoaders": true,
+  "WithLoaders": true, "LoadersFrom": true, "OperationCost": true,
+  "loadersKey": true, "withEventLoaders": true, "eventLoaders": true, "loader": true, "loaderBatch": true,
+  "loaderResult": true, "newLoader": true, "maxOperationCost": true, "costRoots": true, "costField": true,
+  "costMultiplier": true, "costFields": true, "costCounter": true, "costAdd": true, "costMul": true,
+  // server file
+  "Post": true, "Get": true, "ContentTypeJSON": true, "ContentTypeGraphQL": true, "DefaultPath": true,
+  "DefaultReadTimeout": true, "DefaultReadHeaderTimeout": true, "DefaultWriteTimeout": true, "DefaultIdleTimeout": true,
+  "DefaultShutdownTimeout": true, "DefaultPubSubBuffer": true, "DefaultPersistedQueryCacheSize": true,
+  "DefaultExplorerConfig": true, "ExplorerConfigPlaceholder": true, "ExplorerAssetParam": true, "WebSocketProtocol": true,
+  "GqlServer": true, "NewGqlServer": true, "Server": true, "Middleware": true, "Interceptor": true,
+  "OperationHandler": true, "GqlRequest": true, "RequestInfo": true, "WithRequestInfo": true, "RequestInfoFrom": true,
+  "ExplorerConfig": true, "PersistedQueryStore": true, "LRUPersistedQueryStore": true, "NewLRUPersistedQueryStore": true,
+  "PubSub": true, "NewPubSub": true,
+  "request": true, "requestInfoKey": true, "httpServer": true, "httpError": true, "errorResponse": true,
+  "isContentSupported": true, "isMutation": true, "acceptsHTML": true, "explorerPage": true, "gqlExtensions": true,
+  "parse": true, "parseGet": true, "parsePost": true, "persistedQuery": true, "persistedQueryEntry": true,
+  "pubSubscriber": true, "wsMessage": true, "wsConnection": true, "wsOperation": true, "wsInitTimeout": true,
+  "wsWriteTimeout": true, "wsConnectionInit": true, "wsConnectionAck": true, "wsPing": true, "wsPong": true,
+  "wsSubscribe": true, "wsNext": true, "wsError": true, "wsComplete": true,
+}
+
 var KnownGQLTypes = map[string]bool{
   "__Directive":         true,
   "__DirectiveLocation": true,
@@ -57,45 +99,71 @@ var KnownGoTypes = map[string]bool{
 type TypeDef struct {
   Name        string
   Description string
-  Fields      map[string]*FieldDef
+  Kind        string
+  Fields      []*FieldDef
   GQLType     string
-  gqlType     *introspection.Type
+  // Values are the values of an enum type
+  Values []string
+  // PossibleTypes are the names of the types of a union type or of the types implementing an interface type
+  PossibleTypes []string
+  // Binding is the go type a scalar or enum type is bound to
+  Binding *Binding
+  // Operation is the operation type (query, mutation or subscription) of a root resolver interface
+  Operation string
+  gqlType   *introspection.Type
 }
 
-func NewType(t *introspection.Type) *TypeDef {
+func NewType(t *introspection.Type, bindings Bindings) *TypeDef {
 
   tp := &TypeDef{
     Name:        pts(t.Name()),
     Description: pts(t.Description()),
-    Fields:      map[string]*FieldDef{},
+    Kind:        t.Kind(),
+    Fields:      []*FieldDef{},
+    Binding:     bindings[pts(t.Name())],
     gqlType:     t,
   }
 
   /**
-   * union & input object types do not have fields
-   * so we ignore it to avoid nil pointer dereference error
+   * only object & interface types have fields
+   * so we ignore the others to avoid nil pointer dereference error
    * for input object type we create fields from InputFields instead
    */
-  if t.Kind() != gqlUNION && t.Kind() != gqlINPUT_OBJECT {
-    for _, fld := range *t.Fields(nil) {
-      f := NewField(fld)
+  switch t.Kind() {
+  case gqlOBJECT, gqlINTERFACE:
+    // deprecated fields still have to be resolved
+    for _, fld := range *t.Fields(&struct{ IncludeDeprecated bool }{true}) {
+      f := NewField(fld, bindings)
       f.Parent = tp.Name
-      tp.Fields[f.Name] = f
+      f.ArgsParent = tp.Name
+      tp.Fields = append(tp.Fields, f)
+    }
+    if t.Kind() == gqlINTERFACE {
+      for _, pt := range *t.PossibleTypes() {
+        tp.PossibleTypes = append(tp.PossibleTypes, pts(pt.Name()))
+      }
     }
-  } else if t.Kind() == gqlINPUT_OBJECT {
+  case gqlINPUT_OBJECT:
     for _, input := range *t.InputFields() {
-      f := newField(input.Name(), input.Description(), input.Type())
-      f.Parse()
-      tp.Fields[f.Name] = f
+      tp.Fields = append(tp.Fields, newInputField(input, bindings))
     }
+  case gqlUNION:
+    for _, pt := range *t.PossibleTypes() {
+      tp.PossibleTypes = append(tp.PossibleTypes, pts(pt.Name()))
+    }
+  case gqlENUM:
+    tp.Values = enumValues(t)
   }
 
   return tp
 }
 
 type FieldDef struct {
-  Name        string
-  Parent      string
+  Name   string
+  Parent string
+  // ArgsParent is the type whose arguments struct the field uses, the interface declaring the field
+  // for the fields implementing an interface field so they have the same resolver signature, otherwise Parent
+  ArgsParent  string
   Description string
   Type        *Typ
   gqlField    *introspection.Field
@@ -121,22 +189,32 @@ func newField(name string, desc *string, typ *introspection.Type) *FieldDef {
   }
 }
 
-func NewField(t *introspection.Field) *FieldDef {
+func NewField(t *introspection.Field, bindings Bindings) *FieldDef {
 
   fld := newField(t.Name(), t.Description(), t.Type())
-  fld.Parse()
+  fld.gqlField = t
+  fld.Parse(bindings)
 
   // parse arguments (i.e., interface function)
   for _, arg := range t.Args() {
-    argFld := newField(arg.Name(), arg.Description(), arg.Type())
-    argFld.Parse()
-    fld.Args = append(fld.Args, argFld)
+    fld.Args = append(fld.Args, newInputField(arg, bindings))
   }
 
   return fld
 }
 
-func (f *FieldDef) Parse() {
+// newInputField returns the field of an argument or of an input object field. The value of an input with
+// a default value is never null, so graphql-go unmarshals it into a non-pointer like the one of a non-null input
+func newInputField(input *introspection.InputValue, bindings Bindings) *FieldDef {
+  fld := newField(input.Name(), input.Description(), input.Type())
+  fld.Parse(bindings)
+  if input.DefaultValue() != nil {
+    fld.Type.IsNullable = false
+  }
+  return fld
+}
+
+func (f *FieldDef) Parse(bindings Bindings) {
 
   tp := f.Type.gqlType
   td := f.Type
@@ -165,12 +243,15 @@ FindGoType:
   case "String":
     td.GoType = "string"
     td.GQLType = "string"
+    td.Value = true
   case "Int":
     td.GoType = "int32"
     td.GQLType = "int32"
+    td.Value = true
   case "Float":
     td.GoType = "float32"
     td.GQLType = "float32"
+    td.Value = true
   case "ID":
     // TODO - shouldn't we use graphql.ID type for `ID` fields
     // because it may not work for query and mutation calls?
@@ -179,16 +260,44 @@ FindGoType:
   case "Boolean":
     td.GoType = "bool"
     td.GQLType = "bool"
+    td.Value = true
   case "Time":
     td.GoType = "time.Time"
     td.GQLType = "graphql.Time"
+    td.Wrap = "Time"
   default:
     if tp.Kind() == gqlENUM {
-      td.GoType = "string"
-      td.GQLType = "string"
+      td.Enum = true
+      td.GoType = pts(tp.Name())
+      if b, ok := bindings[td.GoType]; ok {
+        td.GoType = b.Type.String()
+      }
+      td.GQLType = td.GoType
+      td.Value = true
+    } else if tp.Kind() == gqlSCALAR {
+      name := pts(tp.Name())
+      b, ok := bindings[name]
+      switch {
+      case !ok:
+        // unmapped scalars get a string based type generated for them
+        td.GoType = name
+        td.GQLType = name
+        td.Value = true
+      case b.Wraps():
+        // the generated wrapper embeds the bound type and converts it with the user's functions
+        td.GoType = b.Type.String()
+        td.GQLType = name
+        td.Wrap = b.Type.Name
+      default:
+        // the bound type implements the graphql-go scalar methods itself
+        td.GoType = b.Type.String()
+        td.GQLType = td.GoType
+        td.Value = true
+      }
     } else {
       td.GoType = pts(tp.Name())
       td.GQLType = pts(tp.Name()) + "Resolver"
+      td.Interface = tp.Kind() == gqlINTERFACE
     }
   }
   return
@@ -200,21 +309,30 @@ type Typ struct {
   IsNullable bool
   Type       *Typ
   Values     []string
-  gqlType    *introspection.Type
+  // Value is set when the resolver returns the struct value as is
+  Value bool
+  // Wrap is the name of the field which holds the struct value in the resolver type (e.g. graphql.Time)
+  Wrap string
+  // Interface is set for interface types whose model is a go interface implemented by the models of the concrete types
+  Interface bool
+  // Enum is set for enum types, which the model stores as a pointer when they are nullable so they can be null
+  Enum    bool
+  gqlType *introspection.Type
 }
 
 func (t Typ) genType(mode string) string {
 
   var r string
 
-  if mode == "struct" || mode == "argStruct" {
+  if mode == "struct" || (mode == "argStruct" && t.Wrap == "") {
     r = t.GoType
   } else {
+    // graphql-go unmarshals the arguments of a wrapped type into its wrapper
     r = t.GQLType
   }
 
   if mode == "struct" {
-    ok := KnownGoTypes[t.GoType]
+    ok := KnownGoTypes[t.GoType] || (t.Value && !t.Enum) || t.Interface
     if t.IsNullable && t.GQLType != "[]" && !ok {
       r = "*" + r
     }
@@ -232,168 +350,153 @@ func (t Typ) genType(mode string) string {
   return r
 }
 
-// FIXME could be refactored and become part of GenStruct()
-func (t *TypeDef) GenInterface() string {
-  r := "type " + t.Name + " interface {\n"
-  for _, f := range t.Fields {
-    r += "  " + f.Name + "("
-    if len(f.Args) > 0 {
-      r += f.Name + "Request"
-    }
-    r += ") " + f.Type.genType("interface") + "\n"
-  }
-  r += "}"
-  return r
+// StructType returns the go type of the field in the model struct
+func (t *Typ) StructType() string {
+  return t.genType("struct")
 }
 
-// FIXME could be refactored and become part of GenResStruct()
-func (t *TypeDef) GenInterfaceResStruct(typePkgName string) string {
-  if typePkgName != "" {
-    typePkgName = typePkgName + "."
-  }
-  r := "type " + t.Name + "Resolver struct {\n"
-  r += "  r *" + t.Name + "\n"
-  r += "}"
-  return r
+// ArgType returns the go type of the field in an input or arguments struct
+func (t *Typ) ArgType() string {
+  return t.genType("argStruct")
 }
 
-func (t *TypeDef) GenStruct(typ string) string {
-  r := "type " + t.Name + " struct {\n"
-  for _, fld := range t.Fields {
-    r += "  " + fld.Name + " " + fld.Type.genType(typ) + "\n"
-  }
-  r += "}"
-  return r
+// ResolverType returns the go type returned by the resolver of the field
+func (t *Typ) ResolverType() string {
+  return t.genType("resolver")
 }
 
-func (t *TypeDef) GenResStruct(typePkgName string) string {
-  if typePkgName != "" {
-    typePkgName = typePkgName + "."
-  }
-  r := "type " + t.Name + "Resolver struct {\n"
-  r += "  R *" + t.Name + "\n"
-  r += "}"
-  return r
+// ListType returns the go type of the slice returned by the resolver of a list field
+func (t *Typ) ListType() string {
+  return strings.TrimPrefix(t.ResolverType(), "*")
 }
 
-// FIXME could be refactored and become part of GenResStruct()
-func (f *FieldDef) GenFuncArgs() string {
-  r := "type " + f.Name + "Request struct {\n"
-  for _, arg := range f.Args {
-    r += "  " + arg.Name + " " + arg.Type.genType("argStruct") + "\n"
-  }
-  r += "}"
-  return r
+func (t *Typ) IsList() bool {
+  return t.GQLType == "[]"
 }
 
-// Resolvers generates the resolver function for the given FieldDef
-func (f *FieldDef) GenResolver() string {
-  res := f.Type.genType("resolver")
-  returnType := res
-  r := "func (r " + f.Parent + "Resolver) " + f.Name + "("
-  r += ") " + res + " {\n"
-  itm := ""
+func (t *Typ) IsID() bool {
+  return t.GQLType == "graphql.ID"
+}
 
-  if f.Type.GQLType == "[]" {
-    if f.Type.IsNullable {
-      returnType = strings.Replace(returnType, "*", "", 1)
-    }
-    if _, ok := KnownGoTypes[f.Type.Type.GoType]; !ok {
-      ref := ""
-      dref := ""
-      if f.Type.Type.IsNullable {
-        itm = "&"
-        dref = "*"
-      } else {
-        ref = "&"
-      }
+// NilCheck reports whether the model stores the value as a pointer which has to be checked for nil
+// before it is converted to the resolver type
+func (t *Typ) NilCheck() bool {
+  return t.IsNullable && !t.IsList() && !t.Enum && strings.HasPrefix(t.StructType(), "*")
+}
 
-      if f.Type.Type.GQLType == "graphql.ID" {
-        itm = f.Type.Type.GQLType + "(itm)"
-      } else if f.Type.Type.GQLType == "graphql.Time" {
-        itm = itm + f.Type.Type.GQLType + "{Time: " + dref + "itm}"
-      } else {
-        itm = itm + f.Type.Type.GQLType + "{" + ref + "itm}"
-      }
-    } else {
-      if f.Type.Type.IsNullable {
-        itm = "&itm"
-      }
-    }
+// TakesAddress reports whether the conversion of `expr` takes its address, which must not be the one of a loop variable
+func (t *Typ) TakesAddress(expr string) bool {
+  return strings.Contains(t.Convert(expr), "&"+expr)
+}
 
-    if itm == "" {
-      itm = "itm"
-    }
-    r += "  items := " + returnType + "{}\n"
-    r += "  for _, itm := range r.R." + f.Name + " {\n"
-    r += "    items = append(items, " + itm + ")\n"
-    r += "  }\n"
-    r += "  return "
-    if f.Type.IsNullable {
-      r += "&"
-    }
-    r += "items\n"
-    r += "}"
-    return r
+// Convert returns the expression converting the model value `expr` to the resolver type
+func (t *Typ) Convert(expr string) string {
+  ref := ""
+  if t.IsNullable {
+    ref = "&"
   }
 
-  if f.Type.GQLType != "graphql.ID" {
-    r += "  return "
-    if f.Type.IsNullable {
-      r += "&"
+  switch {
+  case t.IsID():
+    return "graphql.ID(" + expr + ")"
+  case t.Enum && t.IsNullable:
+    // nullable enums are stored as pointers in the model struct
+    return expr
+  case KnownGoTypes[t.GoType] || t.Value:
+    return ref + expr
+  case t.Interface:
+    // the wrap helper returns a pointer to the resolver of the concrete type
+    if t.IsNullable {
+      return "New" + t.GQLType + "(" + expr + ")"
     }
+    return "*New" + t.GQLType + "(" + expr + ")"
+  case t.Wrap != "":
+    dref := ""
+    if t.IsNullable {
+      dref = "*"
+    }
+    return ref + t.GQLType + "{" + t.Wrap + ": " + dref + expr + "}"
+  default:
+    // nullable fields are stored as pointers in the model struct
+    if t.IsNullable {
+      return ref + t.GQLType + "{" + expr + "}"
+    }
+    return t.GQLType + "{&" + expr + "}"
   }
+}
 
-  if _, ok := KnownGoTypes[f.Type.GQLType]; !ok {
-    if f.Type.GQLType == "graphql.ID" {
-      r += "  id := graphql.ID(r.R." + f.Name + ")\n"
-      r += "  return "
-      if f.Type.IsNullable {
-        r += "&"
-      }
-      r += "id"
-    } else if f.Type.GQLType == "graphql.Time" {
-      dref := ""
-      if f.Type.IsNullable {
-        dref = "*"
-      }
-      r += f.Type.GQLType + "{Time: " + dref + "r.R." + f.Name + "}"
-    } else {
-      ref := ""
-      if !f.Type.IsNullable {
-        ref = "&"
-      }
-      r += f.Type.GQLType + "{" + ref + "r.R." + f.Name + "}"
+// ArgsName returns the name of the struct holding the arguments of the field, i.e., PersonFriendsArgs
+func (f *FieldDef) ArgsName() string {
+  return f.ArgsParent + f.Name + "Args"
+}
+
+// hasID reports whether the type has an `id: ID` field
+func (t *TypeDef) hasID() bool {
+  for _, f := range t.Fields {
+    if f.Name == "ID" && f.Type.IsID() {
+      return true
     }
-  } else {
-    r += "r.R." + f.Name
   }
-
-  r += "\n}"
-  return r
+  return false
 }
 
-func (t *TypeDef) GenUnionResStruct() string {
-  r := "type " + t.Name + "Resolver struct {\n"
-  r += "  Result interface{}\n"
-  r += "}"
-  return r
+// goNames returns the go names declared by the generated code of the type
+func (t *TypeDef) goNames() []string {
+  switch t.Kind {
+  case gqlOBJECT:
+    names := []string{t.Name, t.Name + "Resolver", t.Name + "FieldResolvers"}
+    if t.hasID() {
+      names = append(names, t.Name+"Loader")
+    }
+    return names
+  case gqlINTERFACE:
+    return []string{t.Name, lowerFirst(t.Name) + "Resolver", t.Name + "Resolver", "New" + t.Name + "Resolver"}
+  case gqlUNION:
+    return []string{t.Name + "Resolver"}
+  case gqlINPUT_OBJECT:
+    return []string{t.Name}
+  case gqlSCALAR:
+    if t.Binding == nil || t.Binding.Wraps() {
+      return []string{t.Name}
+    }
+  case gqlENUM:
+    if t.Binding != nil {
+      return []string{"valid" + t.Name, "IsValid" + t.Name}
+    }
+    names := []string{t.Name}
+    for _, v := range t.Values {
+      names = append(names, t.EnumConst(v))
+    }
+    return names
+  }
+  return nil
 }
 
-func (t *TypeDef) GenUnionResolver(parentName string) string {
-  r := "func (r *" + t.Name + "Resolver) To" + parentName + "() (*" + parentName + "Resolver, bool) {\n"
-  r += "  res, ok := r.Result.(*" + parentName + "Resolver)\n"
-  r += "  return res, ok\n"
-  r += "}"
-  return r
+// EnumConst returns the name of the go constant of an enum value
+func (t *TypeDef) EnumConst(value string) string {
+  if t.Binding == nil {
+    return t.Name + enumConstName(value)
+  }
+  constant := t.Binding.Type
+  if t.Binding.Prefix != "" {
+    constant.Name = t.Binding.Prefix
+  }
+  constant.Name += enumConstName(value)
+  return constant.String()
 }
 
 type Generator struct {
   *bytes.Buffer
 
-  PkgName   string
-  rawSchema []byte
-  schema    *graphql.Schema
+  PkgName     string
+  Bindings    Bindings
+  TemplateDir string
+  // ContextResolvers generates resolver methods which take a context.Context and return an error
+  ContextResolvers bool
+  rawSchema        []byte
+  schema           *graphql.Schema
+  // sources maps the locations of the parsed schema to the schema files
+  sources sourceMap
 
   //Param             map[string]string // Command-line parameters.
   //PackageImportPath string            // Go import path of the package we're generating code for
@@ -454,16 +557,36 @@ func New() *Generator {
   g := new(Generator)
   g.Buffer = new(bytes.Buffer)
   g.writeOutput = true
+  g.ContextResolvers = true
   //g.Request = new(plugin.CodeGeneratorRequest)
   //g.Response = new(plugin.CodeGeneratorResponse)
   return g
 }
 
+// Parse parses a schema in SDL or the JSON result of the IntrospectionQuery, which is converted to SDL
 func (g *Generator) Parse(fileData []byte) error {
+  if isIntrospectionJSON(fileData) {
+    sdl, err := IntrospectionToSDL(fileData)
+    if err != nil {
+      return err
+    }
+    fileData = sdl
+  }
   g.rawSchema = fileData
   schema, err := graphql.ParseSchema(string(fileData), nil)
   g.schema = schema
-  return err
+  if err != nil {
+    return g.sources.translate(err)
+  }
+  return nil
+}
+
+// ParseFiles parses the schema files as a single schema.
+// The errors are reported at their position in the files
+func (g *Generator) ParseFiles(files []*SchemaFile) error {
+  var data []byte
+  data, g.sources = concatFiles(files)
+  return g.Parse(data)
 }
 
 func (g *Generator) SetPkgName(name string) *Generator {
@@ -471,6 +594,22 @@ func (g *Generator) SetPkgName(name string) *Generator {
   return g
 }
 
+func (g *Generator) SetContextResolvers(ctx bool) *Generator {
+  g.ContextResolvers = ctx
+  return g
+}
+
+func (g *Generator) SetTemplateDir(dir string) *Generator {
+  g.TemplateDir = dir
+  return g
+}
+
+func (g *Generator) SetBindings(bindings Bindings) *Generator {
+  bindings.resolveAliases()
+  g.Bindings = bindings
+  return g
+}
+
 // In Indents the output one tab stop.
 func (g *Generator) In() { g.indent += "\t" }
 
@@ -509,392 +648,224 @@ func NewSchemaMap() *SchemaMap {
   }
 }
 
+// resolversData is the data of the resolvers template
+type resolversData struct {
+  Types []*TypeDef
+  Args  []*FieldDef
+  Roots []*TypeDef
+  // Loaders are the object types with an `id: ID` field, which get a batch loader
+  Loaders []*TypeDef
+  // Costs are the fields counting in the cost of an operation and Operations the types of the operations
+  Costs      []*costType
+  Operations map[string]string
+  Schema     string
+}
+
 // Fill the buffer with the generated output for all the files we're supposed to generate.
 func (g Generator) GenSchemaResolversFile() []byte {
+  g.execute("resolvers.tmpl", g.resolversData())
+  return g.genFile()
+}
 
-  g.P("package ", g.PkgName)
-  g.P("")
-  // FIXME extract imports out of generator or find a better way to generate them
-  // Check protoc-gen-go codebase
-  g.P("import (")
-  g.In()
-  // FIXME include only when time field is present
-  //g.P(`"time"`)
-  g.P(`graphql "github.com/graph-gophers/graphql-go"`)
-  g.Out()
-  g.P(")")
-  g.P("")
+// resolversData collects the types, argument structs and root resolver interfaces of the schema
+func (g Generator) resolversData() *resolversData {
 
-  types := []*TypeDef{}
-  resType := &TypeDef{
-    Name:   "GqlResolver",
-    Fields: make(map[string]*FieldDef),
+  data := &resolversData{
+    Costs:      g.costTypes(),
+    Operations: g.schema.ASTSchema().EntryPointNames,
+    Schema:     string(g.rawSchema),
   }
-  for _, typ := range g.schema.Inspect().Types() {
+
+  // resolver interfaces of the operation types by the name of the type
+  roots := map[string]*TypeDef{}
+  for _, root := range RootResolvers {
+    if name, ok := g.schema.ASTSchema().EntryPointNames[root.Operation]; ok {
+      roots[name] = &TypeDef{
+        Name:      root.Name,
+        Fields:    []*FieldDef{},
+        Operation: root.Operation,
+      }
+      data.Roots = append(data.Roots, roots[name])
+    }
+  }
+
+  types := []*TypeDef{}
+  for _, typ := range g.types() {
     if KnownGQLTypes[*typ.Name()] {
       continue
     }
+    gtp := NewType(typ, g.Bindings)
+
     switch typ.Kind() {
     case gqlOBJECT:
-      gtp := NewType(typ)
-      typName := pts(typ.Name())
-
-      // save Query & Mutation definitions to be generated later
-      if typName == gqlQuery || typName == gqlMutation {
-        for key, value := range gtp.Fields {
-          resType.Fields[key] = value
-        }
+      // save Query, Mutation & Subscription definitions to be generated later
+      if root, ok := roots[gtp.Name]; ok {
+        root.Fields = append(root.Fields, gtp.Fields...)
       } else {
-        g.P(gtp.GenStruct("struct"))
-        g.P("")
-
-        g.P(gtp.GenResStruct(""))
-        g.P("")
-
-        for _, f := range gtp.Fields {
-          // do not generate a resolver function that has additional arguments
-          // as it requires additional logic
-          // let the user create it manually
-          if len(f.Args) == 0 {
-            g.P(f.GenResolver())
-            g.P("")
-          }
+        data.Types = append(data.Types, gtp)
+        if gtp.hasID() {
+          data.Loaders = append(data.Loaders, gtp)
         }
       }
-
       types = append(types, gtp)
-    case gqlSCALAR:
-      //TODO: Implement scalar type code generation
-      fmt.Printf("%s not implemented yet\n", *typ.Name())
     case gqlINTERFACE:
-      gtp := NewType(typ)
-
-      g.P(gtp.GenInterface())
-      g.P("")
-
-      g.P(gtp.GenInterfaceResStruct(""))
-      g.P("")
-
+      data.Types = append(data.Types, gtp)
       types = append(types, gtp)
     case gqlENUM:
-    //TODO: should we generate a pseudo enum or stick with string?
-    case gqlUNION:
-      gtp := NewType(typ)
-
-      g.P(gtp.GenUnionResStruct())
-      g.P("")
-
-      for _, t := range *typ.PossibleTypes() {
-        g.P(gtp.GenUnionResolver(pts(t.Name())))
-        g.P("")
+      if gtp.Binding != nil && gtp.Binding.Wraps() {
+        g.Fail(g.typePosition(gtp.Name)+":", "enum", gtp.Name, "can not be bound with marshal functions")
       }
-    case gqlINPUT_OBJECT:
-      gtp := NewType(typ)
-      g.P(gtp.GenStruct("argStruct"))
-      g.P("")
+      data.Types = append(data.Types, gtp)
+    case gqlSCALAR, gqlUNION, gqlINPUT_OBJECT:
+      data.Types = append(data.Types, gtp)
     default:
       fmt.Println("unknown graphql type ", *typ.Name(), ":", typ.Kind())
     }
   }
 
-  // generate additional structs for func arguments
-  fncArgs := make(map[string]struct{})
-  for _, t := range types {
-    for _, f := range t.Fields {
-      if len(f.Args) > 0 {
-        fnArgName := f.Name + "Request"
-        _, exists := fncArgs[fnArgName]
-        if exists == false {
-          fncArgs[fnArgName] = struct{}{}
-          g.P(f.GenFuncArgs())
-          g.P("")
-        }
+  /**
+   * graphql-go resolves the fields of all operation types with the same resolver,
+   * so a field name declared by more than one of them would resolve to the same method
+   */
+  rootFields := map[string]string{}
+  for _, root := range data.Roots {
+    for _, f := range root.Fields {
+      if other, exists := rootFields[f.Name]; exists {
+        g.Fail(g.fieldPosition(f)+":", "resolver method", f.Name, "is declared by both", other, "and", root.Name,
+          "but the fields of all operation types are resolved by the same GqlResolver")
       }
+      rootFields[f.Name] = root.Name
     }
   }
 
-  // generate interface for Query & Mutation
-  g.P(resType.GenInterface())
-  g.P("")
-
-  g.P("var Schema = `")
-  g.P(string(g.rawSchema))
-  g.P("`")
-
-  return g.Bytes()
-}
-
-func (g Generator) GenServerFile() []byte {
-
-  g.P("package ", g.PkgName)
-  g.P("")
-  g.P("import (")
-  g.In()
-  g.P(`"encoding/json"`)
-  g.P(`"errors"`)
-  g.P(`"io/ioutil"`)
-  g.P(`"net/http"`)
-  g.P(`"strings"`)
-  g.P(`"sync"`)
-  g.P("")
-  g.P(`graphql "github.com/graph-gophers/graphql-go"`)
-  g.P(`"github.com/rs/cors"`)
-  g.Out()
-  g.P(")")
-  g.P("")
-
-  // generate code to run graphql server
-  g.P(GenServer())
-
-  //out := append(g.Bytes(), srvFile...)
-  //return out
-  return g.Bytes()
-}
-
-func GenServer() string {
-
-  // FIXME should we read this from a file
-  s := `const (
-  ContentTypeJSON    = "application/json"
-  ContentTypeGraphQL = "application/graphql"
-  Post               = "POST"
-  Get                = "GET"
-)
-
-type GqlServer struct {
-  Schema      *graphql.Schema
-  Port        string
-  Request     gqlRequest
-  CorsOptions *cors.Options
-  // TODO add facebook dataloader
-}
-
-func NewGqlServer(res GqlResolver, port string, corsOptions *cors.Options) *GqlServer {
-  return &GqlServer{
-    Schema: graphql.MustParseSchema(Schema, res),
-    Port:   port,
-    CorsOptions: corsOptions,
-  }
-}
-
-func (g *GqlServer) Serve() error {
-
-  // TODO should we validate required fields of GqlServer
-  addr := ":" + g.Port
-  srv := &httpServer{g}
-  mux := http.NewServeMux()
-
-  // configure pre-flight/cors request handler
-  var c *cors.Cors
-  if g.CorsOptions == nil {
-    c = cors.AllowAll()
-  } else {
-    c = cors.New(*g.CorsOptions)
-  }
-
-  mux.Handle("/graphql", srv)
-  handler := c.Handler(mux)
-  return http.ListenAndServe(addr, handler)
-}
-
-type httpServer struct {
-  *GqlServer
-}
-
-func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-
-  if isContentSupported(r.Header.Get("Content-Type")) == false {
-    http.Error(w, "GraphQL only supports json and graphql content type.", http.StatusBadRequest)
-    return
-  }
-
-  req, htpErr := parse(r)
-  if htpErr != nil {
-    http.Error(w, htpErr.message, htpErr.status)
-    return
-  }
-
-  numReqs := len(req.requests)
-  responses := make([]*graphql.Response, numReqs)
-
-  // Use the WaitGroup to wait for all executions to finish
-  // TODO handle facebook data loader
-  var wg sync.WaitGroup
-  wg.Add(numReqs)
-
-  // iterate over all parsed requests and use go routine to process them in parallel
-  for i, q := range req.requests {
-    go func(i int, q gqlRequest) {
-      h.Request = q
-      res := h.Schema.Exec(r.Context(), q.Query, q.OpName, q.Variables)
-      // FIXME expand returned errors to handle a resolver returning more than one error
-      responses[i] = res
-      wg.Done()
-    }(i, q)
-  }
-
-  wg.Wait()
-
-  // TODO should we log errors?
-
-  var err error
-  var resp []byte
-  /**
-    * at this point there should be at least one response.
-    * in case of batch, we send a json array object otherwise a single json object
-   */
-  if req.batch {
-    resp, err = json.Marshal(responses)
-  } else {
-    resp, err = json.Marshal(responses[0])
-  }
-  if err != nil {
-    http.Error(w, "Server error", http.StatusInternalServerError)
-    return
+  // the fields implementing an interface field take the arguments struct of the interface
+  interfaceFields := map[string]map[string]bool{}
+  for _, t := range types {
+    if t.Kind == gqlINTERFACE {
+      interfaceFields[t.Name] = map[string]bool{}
+      for _, f := range t.Fields {
+        interfaceFields[t.Name][f.Name] = true
+      }
+    }
   }
-
-  w.Header().Set("Content-Type", ContentTypeJSON)
-  w.WriteHeader(http.StatusOK)
-  w.Write(resp)
-}
-
-func isContentSupported(contentType string) bool {
-  return strings.HasPrefix(contentType, ContentTypeJSON) || strings.HasPrefix(contentType, ContentTypeGraphQL)
-}
-
-type request struct {
-  requests []gqlRequest
-  batch    bool
-}
-
-type gqlRequest struct {
-  Query     string                 ` + "`" + `json:"query"` + "`" + `
-  OpName    string                 ` + "`" + `json:"operationName"` + "`" + `
-  Variables map[string]interface{} ` + "`" + `json:"variables"` + "`" + `
-}
-
-type httpError struct {
-  status  int
-  message string
-  error
-}
-
-func parse(r *http.Request) (*request, *httpError) {
-
-  if r.Method == Get {
-    return parseGet(r)
-  } else if r.Method == Post {
-    return parsePost(r)
+  for _, t := range types {
+    if t.Kind != gqlOBJECT {
+      continue
+    }
+    for _, iface := range *t.gqlType.Interfaces() {
+      for _, f := range t.Fields {
+        if f.ArgsParent == t.Name && interfaceFields[pts(iface.Name())][f.Name] {
+          f.ArgsParent = pts(iface.Name())
+        }
+      }
+    }
   }
 
-  return nil, &httpError{
-    status:  http.StatusMethodNotAllowed,
-    message: "GraphQL only supports POST and GET requests.",
-    error:   errors.New(r.Method + " is not allowed"),
+  // generated go names, which the go names of the types and the arguments structs must not collide with
+  declared := map[string]string{}
+  for name := range generatedNames {
+    declared[name] = "the generated resolvers and server"
   }
-}
-
-func parseGet(r *http.Request) (*request, *httpError) {
-
-  v := r.URL.Query()
-  var (
-    queries   = v["query"]
-    opNames   = v["operationName"]
-    variables = v["variables"]
-    qLen      = len(queries)
-    nLen      = len(opNames)
-    vLen      = len(variables)
-  )
-
-  if qLen == 0 {
-    return nil, &httpError{
-      status:  http.StatusBadRequest,
-      message: "Missing request parameters",
-      error:   errors.New("missing request parameters"),
+  for _, t := range data.Types {
+    for _, name := range t.goNames() {
+      if decl, exists := declared[name]; exists {
+        g.Fail(g.typePosition(t.Name)+":", "go name", name, "of type", t.Name, "collides with the go types of", decl)
+      }
+      declared[name] = "type " + t.Name
     }
   }
 
-  requests := make([]gqlRequest, 0, qLen)
-
-  // This loop assumes there will be a corresponding element at each index
-  // for query, operation name, and variable fields.
-  // TODO maybe we should do some validation?
-  for i, q := range queries {
-    var opName string
-
-    if i < nLen {
-      opName = opNames[i]
-    }
-
-    var m = map[string]interface{}{}
-    if i < vLen {
-      variable := variables[i]
-      if err := json.Unmarshal([]byte(variable), &m); err != nil {
-        return nil, &httpError{
-          status:  http.StatusBadRequest,
-          message: "Unable to read variables.",
-          error:   err,
+  // generate additional structs for func arguments
+  fncArgs := make(map[string]*FieldDef)
+  for _, t := range types {
+    for _, f := range t.Fields {
+      if len(f.Args) == 0 {
+        continue
+      }
+      fnArgName := f.ArgsName()
+      if other, exists := fncArgs[fnArgName]; exists {
+        // the fields implementing the same interface field share its struct
+        if other.ArgsParent+"."+other.Name != f.ArgsParent+"."+f.Name {
+          g.Fail(g.fieldPosition(f)+":", "arguments struct", fnArgName, "of", f.Parent+"."+f.Name,
+            "collides with the one of", other.Parent+"."+other.Name, "at", g.fieldPosition(other))
         }
+        continue
+      }
+      if decl, exists := declared[fnArgName]; exists {
+        g.Fail(g.fieldPosition(f)+":", "arguments struct", fnArgName, "of", f.Parent+"."+f.Name, "collides with the go types of", decl)
       }
+      fncArgs[fnArgName] = f
+      data.Args = append(data.Args, f)
     }
-
-    requests = append(requests, gqlRequest{q, opName, m})
   }
 
-  return &request{requests: requests, batch: qLen > 1}, nil
+  return data
 }
 
-func parsePost(r *http.Request) (*request, *httpError) {
+// types returns the named types of the schema in the order they are declared
+func (g Generator) types() []*introspection.Type {
+  ast := g.schema.ASTSchema()
+  types := g.schema.Inspect().Types()
+  sort.SliceStable(types, func(i, j int) bool {
+    return typeLocation(ast.Types[*types[i].Name()]).Before(typeLocation(ast.Types[*types[j].Name()]))
+  })
+  return types
+}
 
-  readBodyErr := &httpError{
-    status:  http.StatusBadRequest,
-    message: "Unable to read body.",
+func typeLocation(t gqltypes.NamedType) gqlerrors.Location {
+  switch t := t.(type) {
+  case *gqltypes.ObjectTypeDefinition:
+    return t.Loc
+  case *gqltypes.InterfaceTypeDefinition:
+    return t.Loc
+  case *gqltypes.Union:
+    return t.Loc
+  case *gqltypes.EnumTypeDefinition:
+    return t.Loc
+  case *gqltypes.InputObject:
+    return t.Loc
+  case *gqltypes.ScalarTypeDefinition:
+    return t.Loc
   }
+  return gqlerrors.Location{}
+}
 
-  // read and close the body
-  body, err := ioutil.ReadAll(r.Body)
-  if err != nil {
-    readBodyErr.error = err
-    return nil, readBodyErr
+// genFile adds the package clause and the imports used by the generated code to the output and gofmts it
+func (g Generator) genFile() []byte {
+  body := append([]byte(nil), g.Bytes()...)
+  g.Reset()
+
+  g.P("package ", g.PkgName)
+  g.P("")
+  if imports := g.genImports(body); imports != "" {
+    g.P(imports)
   }
-  r.Body.Close()
+  g.Write(body)
 
-  if len(body) == 0 {
-    return nil, &httpError{
-      status:  http.StatusBadRequest,
-      message: "Missing request body.",
-      error:   errors.New("missing request body"),
+  out, err := format.Source(g.Bytes())
+  if err != nil {
+    // print out the bad code with line numbers to help with debugging
+    s := bufio.NewScanner(bytes.NewReader(g.Bytes()))
+    for line := 1; s.Scan(); line++ {
+      fmt.Fprintf(os.Stderr, "%5d\t%s\n", line, s.Bytes())
     }
+    g.Error(err, "bad go source code was generated")
   }
+  return out
+}
 
-  var requests []gqlRequest
+// GenServerFile generates the server of the parsed schema
+func (g Generator) GenServerFile() []byte {
 
-  // Graphql content type request will send only one query
-  if strings.HasPrefix(r.Header.Get("Content-Type"), ContentTypeGraphQL) {
-    req := gqlRequest{}
-    req.Query = string(body)
-    requests = append(requests, req)
-  } else {
-    // Inspect the first character to inform how the body is parsed.
-    switch body[0] {
-    case '{':
-      req := gqlRequest{}
-      if err := json.Unmarshal(body, &req); err != nil {
-        readBodyErr.error = err
-        return nil, readBodyErr
-      }
-      requests = append(requests, req)
-    case '[':
-      if err := json.Unmarshal(body, &requests); err != nil {
-        readBodyErr.error = err
-        return nil, readBodyErr
-      }
-    }
-  }
+  // generate code to run graphql server
+  g.execute("server.tmpl", g)
+  return g.genFile()
+}
 
-  return &request{requests: requests, batch: len(requests) > 1}, nil
-}`
-  return s
+// Subscriptions reports whether the schema has a subscription type, which the server serves over a websocket
+func (g Generator) Subscriptions() bool {
+  _, ok := g.schema.ASTSchema().EntryPointNames["subscription"]
+  return ok
 }
 
 // Helper functions
@@ -0,0 +1,130 @@
+package generator
+
+import (
+  "bytes"
+  "os"
+  "reflect"
+  "strings"
+  "testing"
+)
+
+// orderSchema declares its types and fields out of alphabetical order
+const orderSchema = `
+schema {
+  query: Query
+}
+
+type Query {
+  zoo(id: ID!): Zoo
+  search(text: String!): [Animal!]!
+}
+
+type Zoo {
+  name: String!
+  id: ID!
+  apes: [Ape!]!
+}
+
+union Animal = Zebra | Ape
+
+type Zebra {
+  stripes: Int!
+}
+
+enum Mood {
+  HAPPY
+  ANGRY
+}
+
+type Ape {
+  mood: Mood
+}
+`
+
+func TestTypesDeclarationOrder(t *testing.T) {
+  g := newTestGenerator(t, orderSchema)
+
+  var names []string
+  for _, typ := range g.types() {
+    if name := *typ.Name(); !KnownGQLTypes[name] && !strings.HasPrefix(name, "__") {
+      names = append(names, name)
+    }
+  }
+  if want := []string{"Query", "Zoo", "Animal", "Zebra", "Mood", "Ape"}; !reflect.DeepEqual(names, want) {
+    t.Errorf("got the types %v, want the declaration order %v", names, want)
+  }
+
+  src := string(g.GenSchemaResolversFile())
+  if want := "type Zoo struct {\n\tName string\n\tID   string\n\tApes []Ape\n}"; !strings.Contains(src, want) {
+    t.Errorf("generated resolvers miss the fields in declaration order\n%s", want)
+  }
+  last := -1
+  for _, decl := range []string{"type Zoo struct", "type AnimalResolver struct", "type Zebra struct", "type Mood string", "type Ape struct"} {
+    i := strings.Index(src, decl)
+    if i < last {
+      t.Errorf("%q is not generated in declaration order", decl)
+    }
+    last = i
+  }
+}
+
+func TestGenSchemaResolversFileIsDeterministic(t *testing.T) {
+  first := newTestGenerator(t, orderSchema).GenSchemaResolversFile()
+  for i := 0; i < 10; i++ {
+    if src := newTestGenerator(t, orderSchema).GenSchemaResolversFile(); !bytes.Equal(src, first) {
+      t.Fatalf("generation %d differs from the first one", i+2)
+    }
+  }
+}
+
+func TestRootResolverInterfaces(t *testing.T) {
+  g := newTestGenerator(t, `
+schema {
+  query: RootQuery
+  mutation: RootMutation
+}
+
+type RootQuery {
+  count: Int!
+}
+
+type RootMutation {
+  increment(by: Int!): Int!
+}
+`)
+
+  src := string(g.GenSchemaResolversFile())
+  for _, want := range []string{
+    "type QueryResolver interface {\n\tCount(ctx context.Context) (int32, error)\n}",
+    "type MutationResolver interface {\n\tIncrement(ctx context.Context, args RootMutationIncrementArgs) (int32, error)\n}",
+    "type GqlResolver interface {\n\tQueryResolver\n\tMutationResolver\n}",
+  } {
+    if !strings.Contains(src, want) {
+      t.Errorf("generated resolvers miss\n%s", want)
+    }
+  }
+  if strings.Contains(src, "SubscriptionResolver") || strings.Contains(src, "type RootQuery struct") {
+    t.Error("generated resolvers have the go types of a missing or of a root operation type")
+  }
+}
+
+func TestRootFieldCollisions(t *testing.T) {
+  if os.Getenv("ROOT_FIELD_COLLISION") != "" {
+    g := New().SetPkgName("api")
+    err := g.ParseFiles([]*SchemaFile{
+      {Name: "query.graphql", Content: []byte("schema {\n  query: Query\n  mutation: Mutation\n}\n\ntype Query {\n  person: Int\n}\n")},
+      {Name: "mutation.graphql", Content: []byte("type Mutation {\n  create: Int\n  person(name: String!): Int\n}\n")},
+    })
+    if err != nil {
+      t.Fatal(err)
+    }
+    g.resolversData()
+    return
+  }
+
+  want := "mutation.graphql:3:3: resolver method Person is declared by both QueryResolver and MutationResolver " +
+    "but the fields of all operation types are resolved by the same GqlResolver"
+  if out := runFailing(t, "TestRootFieldCollisions", "ROOT_FIELD_COLLISION=1"); !strings.Contains(out, want) {
+    t.Errorf("got %q, want it to contain %q", out, want)
+  }
+}
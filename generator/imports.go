@@ -0,0 +1,213 @@
+package generator
+
+import (
+  "go/ast"
+  "go/parser"
+  "go/token"
+  "path"
+  "sort"
+  "strconv"
+  "strings"
+  "unicode"
+)
+
+// KnownImports are the packages which can be referenced by the generated code
+// mapped to the names they are referenced with
+var KnownImports = map[string]string{
+  "bytes":          "bytes",
+  "container/list": "list",
+  "context":        "context",
+  "crypto/sha256":  "sha256",
+  "encoding/hex":   "hex",
+  "encoding/json":  "json",
+  "errors":         "errors",
+  "fmt":            "fmt",
+  "io/fs":          "fs",
+  "io/ioutil":      "ioutil",
+  "math":           "math",
+  "net/http":       "http",
+  "strings":        "strings",
+  "sync":           "sync",
+  "time":           "time",
+  "github.com/dealtap/graphql-gen-go/gqlquery": "gqlquery",
+  "github.com/gorilla/websocket":               "websocket",
+  "github.com/graph-gophers/graphql-go":        "graphql",
+  "github.com/graph-gophers/graphql-go/errors": "gqlerrors",
+  "github.com/rs/cors":                         "cors",
+}
+
+// pkgName guesses the name of a package from its import path
+// i.e., gopkg.in/yaml.v2 -> yaml, github.com/satori/go.uuid -> uuid, github.com/acme/types/v2 -> types
+func pkgName(importPath string) string {
+  name := path.Base(importPath)
+  if len(name) > 1 && name[0] == 'v' && isDigits(name[1:]) && path.Dir(importPath) != "." {
+    name = path.Base(path.Dir(importPath))
+  }
+  if i := strings.Index(name, ".v"); i > 0 && isDigits(name[i+2:]) {
+    name = name[:i]
+  }
+  name = strings.TrimPrefix(name, "go-")
+  name = strings.TrimPrefix(name, "go.")
+  name = strings.TrimSuffix(name, "-go")
+
+  name = strings.Map(func(r rune) rune {
+    if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+      return r
+    }
+    return -1
+  }, name)
+  if name == "" || unicode.IsDigit(rune(name[0])) {
+    name = "pkg" + name
+  }
+  return name
+}
+
+func isDigits(s string) bool {
+  if s == "" {
+    return false
+  }
+  for _, r := range s {
+    if !unicode.IsDigit(r) {
+      return false
+    }
+  }
+  return true
+}
+
+// resolveAliases assigns the names the bound packages are referenced with.
+// Packages are named in the order of their import path, so the aliases do not depend
+// on the order of the bindings or on which of them are used by the schema
+func (bindings Bindings) resolveAliases() {
+  pkgs := map[string]bool{}
+  for _, b := range bindings {
+    for _, pkg := range b.packages() {
+      pkgs[pkg] = true
+    }
+  }
+
+  sorted := make([]string, 0, len(pkgs))
+  for pkg := range pkgs {
+    sorted = append(sorted, pkg)
+  }
+  sort.Strings(sorted)
+
+  taken := map[string]bool{}
+  for _, name := range KnownImports {
+    taken[name] = true
+  }
+
+  aliases := map[string]string{}
+  for _, pkg := range sorted {
+    if name, ok := KnownImports[pkg]; ok {
+      aliases[pkg] = name
+      continue
+    }
+    name := pkgName(pkg)
+    alias := name
+    for i := 2; taken[alias]; i++ {
+      alias = name + strconv.Itoa(i)
+    }
+    taken[alias] = true
+    aliases[pkg] = alias
+  }
+
+  for _, b := range bindings {
+    for _, ref := range []*GoRef{&b.Type, &b.Marshal, &b.Unmarshal} {
+      if ref.Pkg != "" {
+        ref.alias = aliases[ref.Pkg]
+      }
+    }
+  }
+}
+
+// imports returns the packages referenced by the bindings mapped to their names
+func (bindings Bindings) imports() map[string]string {
+  imports := map[string]string{}
+  for _, b := range bindings {
+    for _, ref := range []GoRef{b.Type, b.Marshal, b.Unmarshal} {
+      if ref.Pkg != "" {
+        imports[ref.Pkg] = ref.pkgName()
+      }
+    }
+  }
+  return imports
+}
+
+// genImports generates the import block of a file for the packages used by its body.
+// The body is parsed to find the package names it references but does not declare
+func (g Generator) genImports(body []byte) string {
+  src := "package " + g.PkgName + "\n\n" + string(body)
+  file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+  if err != nil {
+    // let format report the syntax error along with the generated source
+    return ""
+  }
+
+  return importBlock(g.missingImports(file))
+}
+
+// importBlock returns an import declaration of the standard and other packages in separate groups
+func importBlock(std, other []string) string {
+  if len(std)+len(other) == 0 {
+    return ""
+  }
+
+  r := "import (\n"
+  for _, imp := range std {
+    r += "  " + imp + "\n"
+  }
+  if len(std) > 0 && len(other) > 0 {
+    r += "\n"
+  }
+  for _, imp := range other {
+    r += "  " + imp + "\n"
+  }
+  r += ")\n"
+  return r
+}
+
+// missingImports returns the sorted import specs of the known and bound packages
+// which are referenced by the file but not imported by it, split into standard and other packages
+func (g Generator) missingImports(file *ast.File) (std, other []string) {
+  used := map[string]bool{}
+  for _, ident := range file.Unresolved {
+    used[ident.Name] = true
+  }
+
+  // the file may import some of the packages already
+  for _, spec := range file.Imports {
+    pkg, _ := strconv.Unquote(spec.Path.Value)
+    name, ok := KnownImports[pkg]
+    if !ok {
+      name = pkgName(pkg)
+    }
+    if spec.Name != nil {
+      name = spec.Name.Name
+    }
+    delete(used, name)
+  }
+
+  candidates := g.Bindings.imports()
+  for pkg, name := range KnownImports {
+    candidates[pkg] = name
+  }
+
+  for pkg, name := range candidates {
+    if !used[name] {
+      continue
+    }
+    imp := strconv.Quote(pkg)
+    if name != path.Base(pkg) {
+      imp = name + " " + imp
+    }
+    if strings.Contains(strings.SplitN(pkg, "/", 2)[0], ".") {
+      other = append(other, imp)
+    } else {
+      std = append(std, imp)
+    }
+  }
+
+  sort.Strings(std)
+  sort.Strings(other)
+  return std, other
+}
@@ -0,0 +1,65 @@
+package generator
+
+import "testing"
+
+func TestPkgName(t *testing.T) {
+  for importPath, want := range map[string]string{
+    "time":                       "time",
+    "github.com/google/uuid":     "uuid",
+    "gopkg.in/yaml.v2":           "yaml",
+    "github.com/satori/go.uuid":  "uuid",
+    "github.com/acme/types/v2":   "types",
+    "github.com/acme/money-go":   "money",
+    "github.com/acme/go-decimal": "decimal",
+    "example.com/2fa":            "pkg2fa",
+  } {
+    if got := pkgName(importPath); got != want {
+      t.Errorf("got %s for %s, want %s", got, importPath, want)
+    }
+  }
+}
+
+func TestGenImports(t *testing.T) {
+  bindings, err := ParseBindings([]string{
+    "UUID=github.com/google/uuid.UUID",
+    "ID=github.com/acme/uuid.ID",
+    "Err=example.com/errors.Code",
+    "Unused=example.com/unused.Type",
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  bindings.resolveAliases()
+  g := New().SetPkgName("api").SetBindings(bindings)
+
+  body := `
+type Item struct {
+  ID   uuid2.UUID
+  Acme uuid.ID
+  Code errors2.Code
+}
+
+func (i Item) String(strings []string) string {
+  return fmt.Sprint(i.ID, errors.New("x"), graphql.ID(""), strings)
+}
+`
+  // the packages of the same name are aliased in the order of their import paths, strings is a parameter
+  // and the packages of the unused bindings are not imported
+  want := `import (
+  "errors"
+  "fmt"
+
+  "github.com/acme/uuid"
+  errors2 "example.com/errors"
+  graphql "github.com/graph-gophers/graphql-go"
+  uuid2 "github.com/google/uuid"
+)
+`
+  if got := g.genImports([]byte(body)); got != want {
+    t.Errorf("got the imports\n%s\nwant\n%s", got, want)
+  }
+
+  if got := g.genImports([]byte("type Item struct{}\n")); got != "" {
+    t.Errorf("got the imports %q for a file without references", got)
+  }
+}
@@ -0,0 +1,81 @@
+package generator
+
+import (
+  "strings"
+  "testing"
+)
+
+const interfaceSchema = `
+schema {
+  query: Query
+}
+
+type Query {
+  node(id: ID!): Node
+  nodes: [Node!]!
+}
+
+interface Node {
+  id: ID!
+  children(first: Int): [Node!]!
+}
+
+type Person implements Node {
+  id: ID!
+  name: String!
+  children(first: Int): [Node!]!
+}
+
+type Folder implements Node {
+  id: ID!
+  parent: Node
+  children(first: Int): [Node!]!
+}
+`
+
+func TestInterfaceResolvers(t *testing.T) {
+  for name, test := range map[string]struct {
+    ctx  bool
+    want []string
+  }{
+    "context resolvers": {
+      ctx: true,
+      want: []string{
+        "func (r NodeResolver) Children(ctx context.Context, args NodeChildrenArgs) ([]NodeResolver, error) {\n\treturn r.Result.Children(ctx, args)\n}",
+        // the implementations of the interface field take the arguments struct of the interface
+        "Children(ctx context.Context, args NodeChildrenArgs) ([]NodeResolver, error)\n}\n\nvar _ PersonFieldResolvers = PersonResolver{}",
+        "func (r FolderResolver) Parent(ctx context.Context) (*NodeResolver, error) {\n\treturn NewNodeResolver(r.R.Parent), nil\n}",
+      },
+    },
+    "legacy resolvers": {
+      want: []string{
+        "func (r NodeResolver) Children(args NodeChildrenArgs) []NodeResolver {\n\treturn r.Result.Children(args)\n}",
+        "func (r NodeResolver) ID() graphql.ID {\n\treturn r.Result.ID()\n}",
+      },
+    },
+  } {
+    t.Run(name, func(t *testing.T) {
+      g := newTestGenerator(t, interfaceSchema).SetContextResolvers(test.ctx)
+      src := string(g.GenSchemaResolversFile())
+      for _, want := range append(test.want,
+        "type Node interface {\n\tisNode()\n}",
+        "func (*Person) isNode() {}",
+        "func (*Folder) isNode() {}",
+        "type NodeResolver struct {\n\tResult nodeResolver\n}",
+        "func (r NodeResolver) ToFolder() (*FolderResolver, bool) {",
+        "case *Person:\n\t\treturn &NodeResolver{&PersonResolver{m}}",
+        "type Folder struct {\n\tID       string\n\tParent   Node\n\tChildren []Node\n}",
+      ) {
+        if !strings.Contains(src, want) {
+          t.Errorf("generated resolvers miss\n%s", want)
+        }
+      }
+      for _, unwanted := range []string{"PersonChildrenArgs", "FolderChildrenArgs"} {
+        if strings.Contains(src, unwanted) {
+          t.Errorf("generated resolvers declare %s", unwanted)
+        }
+      }
+      compileGenerated(t, g, nil)
+    })
+  }
+}
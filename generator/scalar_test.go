@@ -0,0 +1,237 @@
+package generator
+
+import (
+  "fmt"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+// compileGenerated writes the resolvers, server and stubs files generated by g next to the hand-written files
+// into a package of the module and vets it, which fails the test when the generated code does not compile
+func compileGenerated(t *testing.T, g *Generator, files map[string]string) {
+  t.Helper()
+  if out, err := vetPackage(genPackage(t, g, files)); err != nil {
+    t.Fatalf("the generated code does not compile: %v\n%s", err, out)
+  }
+}
+
+// genPackage writes the generated files next to the hand-written ones into a package of the module
+// and returns its directory, the test is skipped when the package can not be compiled
+func genPackage(t *testing.T, g *Generator, files map[string]string) string {
+  t.Helper()
+  if testing.Short() {
+    t.Skip("the generated code is not compiled in short mode")
+  }
+  if _, err := exec.LookPath("go"); err != nil {
+    t.Skip("the go command is not available")
+  }
+
+  // the package must be inside the module to import graphql-go, the directories of testdata are not matched by ./...
+  if err := os.MkdirAll("testdata", 0755); err != nil {
+    t.Fatal(err)
+  }
+  dir, err := os.MkdirTemp("testdata", "compile")
+  if err != nil {
+    t.Fatal(err)
+  }
+  t.Cleanup(func() {
+    os.RemoveAll(dir)
+    os.Remove("testdata")
+  })
+
+  write := func(name string, src []byte) {
+    if err := os.WriteFile(filepath.Join(dir, name), src, 0644); err != nil {
+      t.Fatal(err)
+    }
+  }
+  for name, src := range files {
+    write(name, []byte(src))
+  }
+  // the generators share the buffer of g, which holds the last generated file
+  g.Reset()
+  write(g.PkgName+".gql.go", g.GenSchemaResolversFile())
+  g.Reset()
+  write("server.gql.go", g.GenServerFile())
+  g.Reset()
+  if stubs := g.GenStubsFile(dir, nil); stubs != nil {
+    write(g.PkgName+"-extra.go", stubs)
+  }
+
+  return dir
+}
+
+// vetPackage runs go vet on the package in dir, a directory relative to the one of the test
+func vetPackage(dir string) ([]byte, error) {
+  return exec.Command("go", "vet", "./"+filepath.ToSlash(dir)).CombinedOutput()
+}
+
+// scalarSchema uses the scalar as a required, nullable and list value of fields, arguments and inputs
+const scalarSchema = `
+schema {
+  query: Query
+}
+
+scalar %[1]s
+
+type Query {
+  item(at: %[1]s, all: [%[1]s!]): Item
+}
+
+type Item {
+  id: ID!
+  value: %[1]s!
+  optional: %[1]s
+  values: [%[1]s!]!
+  optionalValues: [%[1]s]
+  filtered(filter: Filter!): [%[1]s]
+}
+
+input Filter {
+  value: %[1]s
+  values: [%[1]s!]
+}
+`
+
+func TestScalarBindings(t *testing.T) {
+  for name, test := range map[string]struct {
+    scalar  string
+    binding string
+    // files are the hand-written files of the package declaring the bound types and functions
+    files map[string]string
+    want  []string
+  }{
+    "unbound": {
+      scalar: "Color",
+      want: []string{
+        "type Color string",
+        "\tValue          Color\n",
+        "\tOptional       Color\n",
+        "\tOptionalValues []Color\n",
+        "func (r ItemResolver) Optional(ctx context.Context) (*Color, error) {\n\treturn &r.R.Optional, nil\n}",
+        "type QueryItemArgs struct {\n\tAt  *Color\n\tAll *[]Color\n}",
+        "type Filter struct {\n\tValue  *Color\n\tValues *[]Color\n}",
+      },
+    },
+    "bound to a type implementing the plumbing": {
+      scalar:  "JSON",
+      binding: "JSON=RawJSON",
+      files: map[string]string{"json.go": `package api
+
+import "encoding/json"
+
+type RawJSON struct {
+  Value interface{}
+}
+
+func (RawJSON) ImplementsGraphQLType(name string) bool {
+  return name == "JSON"
+}
+
+func (j *RawJSON) UnmarshalGraphQL(input interface{}) error {
+  j.Value = input
+  return nil
+}
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+  return json.Marshal(j.Value)
+}
+`},
+      want: []string{
+        "\tValue          RawJSON\n",
+        "\tOptionalValues []RawJSON\n",
+        "type QueryItemArgs struct {\n\tAt  *RawJSON\n\tAll *[]RawJSON\n}",
+        "type Filter struct {\n\tValue  *RawJSON\n\tValues *[]RawJSON\n}",
+      },
+    },
+    "wrapped with marshal functions": {
+      scalar:  "Date",
+      binding: "Date=time.Time,marshal=MarshalDate,unmarshal=UnmarshalDate",
+      files: map[string]string{"date.go": `package api
+
+import (
+  "fmt"
+  "time"
+)
+
+func MarshalDate(t time.Time) (interface{}, error) {
+  return t.Format("2006-01-02"), nil
+}
+
+func UnmarshalDate(input interface{}) (time.Time, error) {
+  s, ok := input.(string)
+  if !ok {
+    return time.Time{}, fmt.Errorf("wrong type for Date: %T", input)
+  }
+  return time.Parse("2006-01-02", s)
+}
+`},
+      want: []string{
+        "type Date struct {\n\ttime.Time\n}",
+        "v, err := UnmarshalDate(input)",
+        "v, err := MarshalDate(s.Time)",
+        "\tValue          time.Time\n",
+        "\tOptional       *time.Time\n",
+        "\tOptionalValues []*time.Time\n",
+        // the nullable values are checked for nil before they are wrapped
+        "if r.R.Optional == nil {\n\t\treturn nil, nil\n\t}\n\treturn &Date{Time: *r.R.Optional}, nil",
+        "if itm == nil {\n\t\t\titems = append(items, nil)\n\t\t\tcontinue\n\t\t}\n\t\titems = append(items, &Date{Time: *itm})",
+        "items = append(items, Date{Time: itm})",
+        // graphql-go unmarshals the arguments and inputs into the wrapper
+        "type QueryItemArgs struct {\n\tAt  *Date\n\tAll *[]Date\n}",
+        "type Filter struct {\n\tValue  *Date\n\tValues *[]Date\n}",
+      },
+    },
+    "wrapped with an unmarshal function": {
+      scalar:  "Amount",
+      binding: "Amount=math/big.Float,unmarshal=ParseAmount",
+      files: map[string]string{"amount.go": `package api
+
+import (
+  "fmt"
+  "math/big"
+)
+
+func ParseAmount(input interface{}) (big.Float, error) {
+  var f big.Float
+  s, ok := input.(string)
+  if !ok {
+    return f, fmt.Errorf("wrong type for Amount: %T", input)
+  }
+  _, _, err := f.Parse(s, 10)
+  return f, err
+}
+`},
+      want: []string{
+        "type Amount struct {\n\tbig.Float\n}",
+        "\tOptional       *big.Float\n",
+        "return &Amount{Float: *r.R.Optional}",
+        "type Filter struct {\n\tValue  *Amount\n\tValues *[]Amount\n}",
+      },
+    },
+  } {
+    t.Run(name, func(t *testing.T) {
+      g := newTestGenerator(t, fmt.Sprintf(scalarSchema, test.scalar))
+      if test.binding != "" {
+        bindings, err := ParseBindings([]string{test.binding})
+        if err != nil {
+          t.Fatal(err)
+        }
+        g.SetBindings(bindings)
+      }
+
+      src := string(g.GenSchemaResolversFile())
+      for _, want := range test.want {
+        if !strings.Contains(src, want) {
+          t.Errorf("generated resolvers miss\n%s", want)
+        }
+      }
+      if test.binding != "" && !strings.Contains(test.binding, "unmarshal=") && strings.Contains(src, "type "+test.scalar+" ") {
+        t.Errorf("generated resolvers declare the bound type %s", test.scalar)
+      }
+      compileGenerated(t, g, test.files)
+    })
+  }
+}
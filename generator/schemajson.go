@@ -0,0 +1,358 @@
+package generator
+
+import (
+  "bytes"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "strconv"
+  "strings"
+)
+
+// IntrospectionQuery is the standard introspection query, its result can be converted to SDL with IntrospectionToSDL
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      locations
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+            ofType {
+              kind
+              name
+              ofType {
+                kind
+                name
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
+
+// builtinScalars and builtinDirectives are part of every schema so they are not printed
+var builtinScalars = map[string]bool{"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true}
+var builtinDirectives = map[string]bool{"skip": true, "include": true, "deprecated": true, "specifiedBy": true}
+
+// defaultDeprecationReason is the reason of a `@deprecated` directive without one
+const defaultDeprecationReason = "No longer supported"
+
+// introspectionResult is the result of the introspection query, either as the response
+// of a server (`{"data": {"__schema": ...}}`) or its data only (`{"__schema": ...}`)
+type introspectionResult struct {
+  Data *struct {
+    Schema *introspectionSchema `json:"__schema"`
+  } `json:"data"`
+  Schema *introspectionSchema `json:"__schema"`
+  Errors []struct {
+    Message string `json:"message"`
+  } `json:"errors"`
+}
+
+type introspectionSchema struct {
+  QueryType        *introspectionTypeRef     `json:"queryType"`
+  MutationType     *introspectionTypeRef     `json:"mutationType"`
+  SubscriptionType *introspectionTypeRef     `json:"subscriptionType"`
+  Types            []*introspectionType      `json:"types"`
+  Directives       []*introspectionDirective `json:"directives"`
+}
+
+type introspectionType struct {
+  Kind          string                     `json:"kind"`
+  Name          string                     `json:"name"`
+  Description   *string                    `json:"description"`
+  Fields        []*introspectionField      `json:"fields"`
+  InputFields   []*introspectionInputValue `json:"inputFields"`
+  Interfaces    []*introspectionTypeRef    `json:"interfaces"`
+  EnumValues    []*introspectionEnumValue  `json:"enumValues"`
+  PossibleTypes []*introspectionTypeRef    `json:"possibleTypes"`
+}
+
+type introspectionTypeRef struct {
+  Kind   string                `json:"kind"`
+  Name   *string               `json:"name"`
+  OfType *introspectionTypeRef `json:"ofType"`
+}
+
+type introspectionField struct {
+  Name              string                     `json:"name"`
+  Description       *string                    `json:"description"`
+  Args              []*introspectionInputValue `json:"args"`
+  Type              *introspectionTypeRef      `json:"type"`
+  IsDeprecated      bool                       `json:"isDeprecated"`
+  DeprecationReason *string                    `json:"deprecationReason"`
+}
+
+type introspectionInputValue struct {
+  Name         string                `json:"name"`
+  Description  *string               `json:"description"`
+  Type         *introspectionTypeRef `json:"type"`
+  DefaultValue *string               `json:"defaultValue"`
+}
+
+type introspectionEnumValue struct {
+  Name              string  `json:"name"`
+  Description       *string `json:"description"`
+  IsDeprecated      bool    `json:"isDeprecated"`
+  DeprecationReason *string `json:"deprecationReason"`
+}
+
+type introspectionDirective struct {
+  Name        string                     `json:"name"`
+  Description *string                    `json:"description"`
+  Locations   []string                   `json:"locations"`
+  Args        []*introspectionInputValue `json:"args"`
+}
+
+// isIntrospectionJSON reports whether the schema data is an introspection result rather than SDL
+func isIntrospectionJSON(data []byte) bool {
+  data = bytes.TrimSpace(data)
+  return len(data) > 0 && data[0] == '{'
+}
+
+// IntrospectionToSDL converts the JSON result of the IntrospectionQuery to a schema in SDL.
+// Descriptions are printed as comments, which is how graphql-go reads them by default
+func IntrospectionToSDL(data []byte) ([]byte, error) {
+  var res introspectionResult
+  if err := json.Unmarshal(data, &res); err != nil {
+    return nil, fmt.Errorf("invalid introspection result: %v", err)
+  }
+  if len(res.Errors) > 0 {
+    var msgs []string
+    for _, e := range res.Errors {
+      msgs = append(msgs, e.Message)
+    }
+    return nil, errors.New("introspection failed: " + strings.Join(msgs, ", "))
+  }
+
+  schema := res.Schema
+  if res.Data != nil {
+    schema = res.Data.Schema
+  }
+  if schema == nil || schema.QueryType == nil {
+    return nil, errors.New("invalid introspection result: no __schema with a query type")
+  }
+
+  p := &sdlPrinter{}
+  p.printSchema(schema)
+  return p.Bytes(), nil
+}
+
+// sdlPrinter prints the types of an introspection result
+type sdlPrinter struct {
+  bytes.Buffer
+}
+
+func (p *sdlPrinter) printSchema(s *introspectionSchema) {
+  p.WriteString("schema {\n")
+  for _, root := range []struct {
+    operation string
+    ref       *introspectionTypeRef
+  }{
+    {"query", s.QueryType},
+    {"mutation", s.MutationType},
+    {"subscription", s.SubscriptionType},
+  } {
+    if root.ref != nil && root.ref.Name != nil {
+      p.WriteString("  " + root.operation + ": " + *root.ref.Name + "\n")
+    }
+  }
+  p.WriteString("}\n")
+
+  for _, d := range s.Directives {
+    if builtinDirectives[d.Name] {
+      continue
+    }
+    p.WriteString("\n")
+    p.printDescription("", d.Description)
+    p.WriteString("directive @" + d.Name + p.args("", d.Args) + " on " + strings.Join(d.Locations, " | ") + "\n")
+  }
+
+  for _, t := range s.Types {
+    if strings.HasPrefix(t.Name, "__") || builtinScalars[t.Name] {
+      continue
+    }
+    p.WriteString("\n")
+    p.printType(t)
+  }
+}
+
+func (p *sdlPrinter) printType(t *introspectionType) {
+  p.printDescription("", t.Description)
+
+  switch t.Kind {
+  case gqlSCALAR:
+    p.WriteString("scalar " + t.Name + "\n")
+  case gqlOBJECT, gqlINTERFACE:
+    keyword := "type "
+    if t.Kind == gqlINTERFACE {
+      keyword = "interface "
+    }
+    p.WriteString(keyword + t.Name + implements(t.Interfaces) + " {\n")
+    for _, f := range t.Fields {
+      p.printDescription("  ", f.Description)
+      p.WriteString("  " + f.Name + p.args("  ", f.Args) + ": " + typeRef(f.Type))
+      p.WriteString(deprecated(f.IsDeprecated, f.DeprecationReason) + "\n")
+    }
+    p.WriteString("}\n")
+  case gqlINPUT_OBJECT:
+    p.WriteString("input " + t.Name + " {\n")
+    for _, f := range t.InputFields {
+      p.printDescription("  ", f.Description)
+      p.WriteString("  " + inputValue(f) + "\n")
+    }
+    p.WriteString("}\n")
+  case gqlUNION:
+    var names []string
+    for _, pt := range t.PossibleTypes {
+      names = append(names, pts(pt.Name))
+    }
+    p.WriteString("union " + t.Name + " = " + strings.Join(names, " | ") + "\n")
+  case gqlENUM:
+    p.WriteString("enum " + t.Name + " {\n")
+    for _, v := range t.EnumValues {
+      p.printDescription("  ", v.Description)
+      p.WriteString("  " + v.Name + deprecated(v.IsDeprecated, v.DeprecationReason) + "\n")
+    }
+    p.WriteString("}\n")
+  }
+}
+
+// printDescription prints a description as comment lines
+func (p *sdlPrinter) printDescription(indent string, desc *string) {
+  if desc == nil || *desc == "" {
+    return
+  }
+  for _, line := range strings.Split(*desc, "\n") {
+    p.WriteString(strings.TrimRight(indent+"# "+line, " ") + "\n")
+  }
+}
+
+// args returns the argument list of a field or directive,
+// the arguments are printed on their own lines when any of them has a description
+func (p *sdlPrinter) args(indent string, args []*introspectionInputValue) string {
+  if len(args) == 0 {
+    return ""
+  }
+
+  multiline := false
+  for _, a := range args {
+    multiline = multiline || (a.Description != nil && *a.Description != "")
+  }
+
+  if !multiline {
+    var list []string
+    for _, a := range args {
+      list = append(list, inputValue(a))
+    }
+    return "(" + strings.Join(list, ", ") + ")"
+  }
+
+  arg := &sdlPrinter{}
+  arg.WriteString("(\n")
+  for _, a := range args {
+    arg.printDescription(indent+"  ", a.Description)
+    arg.WriteString(indent + "  " + inputValue(a) + "\n")
+  }
+  arg.WriteString(indent + ")")
+  return arg.String()
+}
+
+func inputValue(v *introspectionInputValue) string {
+  s := v.Name + ": " + typeRef(v.Type)
+  if v.DefaultValue != nil {
+    s += " = " + *v.DefaultValue
+  }
+  return s
+}
+
+// typeRef returns the type reference in SDL i.e., `[Person!]!`
+func typeRef(t *introspectionTypeRef) string {
+  switch t.Kind {
+  case "NON_NULL":
+    return typeRef(t.OfType) + "!"
+  case gqlLIST:
+    return "[" + typeRef(t.OfType) + "]"
+  }
+  return pts(t.Name)
+}
+
+func implements(interfaces []*introspectionTypeRef) string {
+  if len(interfaces) == 0 {
+    return ""
+  }
+  var names []string
+  for _, i := range interfaces {
+    names = append(names, pts(i.Name))
+  }
+  return " implements " + strings.Join(names, " & ")
+}
+
+func deprecated(isDeprecated bool, reason *string) string {
+  if !isDeprecated {
+    return ""
+  }
+  if reason == nil || *reason == defaultDeprecationReason {
+    return " @deprecated"
+  }
+  return " @deprecated(reason: " + strconv.Quote(*reason) + ")"
+}
@@ -0,0 +1,182 @@
+package generator
+
+import (
+  "strings"
+  "testing"
+)
+
+// introspectionJSON is the introspection result of introspectionSDL, with the builtin types and directives left out
+const introspectionJSON = `{"data": {"__schema": {
+  "queryType": {"name": "Query"},
+  "mutationType": {"name": "Mutation"},
+  "subscriptionType": null,
+  "directives": [
+    {"name": "include", "locations": ["FIELD"], "args": [
+      {"name": "if", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Boolean"}}}
+    ]},
+    {"name": "cost", "description": "The cost of a field", "locations": ["FIELD_DEFINITION", "OBJECT"], "args": [
+      {"name": "weight", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "1"}
+    ]}
+  ],
+  "types": [
+    {"kind": "OBJECT", "name": "__Schema", "fields": []},
+    {"kind": "SCALAR", "name": "String"},
+    {"kind": "SCALAR", "name": "Time", "description": "An RFC 3339 time"},
+    {"kind": "OBJECT", "name": "Query", "fields": [
+      {"name": "search", "args": [
+        {"name": "text", "description": "The searched text", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+        {"name": "first", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "10"}
+      ], "type": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "UNION", "name": "Result"}}}}},
+      {"name": "node", "args": [
+        {"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}
+      ], "type": {"kind": "INTERFACE", "name": "Node"}}
+    ]},
+    {"kind": "OBJECT", "name": "Mutation", "fields": [
+      {"name": "createPerson", "args": [
+        {"name": "input", "type": {"kind": "NON_NULL", "ofType": {"kind": "INPUT_OBJECT", "name": "PersonInput"}}}
+      ], "type": {"kind": "OBJECT", "name": "Person"}}
+    ]},
+    {"kind": "INTERFACE", "name": "Node", "fields": [
+      {"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}
+    ], "possibleTypes": [{"kind": "OBJECT", "name": "Person"}]},
+    {"kind": "OBJECT", "name": "Person", "description": "A person\nof the catalog", "interfaces": [{"kind": "INTERFACE", "name": "Node"}], "fields": [
+      {"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+      {"name": "name", "description": "The full name", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+      {"name": "born", "args": [], "type": {"kind": "SCALAR", "name": "Time"}, "isDeprecated": true, "deprecationReason": "No longer supported"},
+      {"name": "role", "args": [], "type": {"kind": "ENUM", "name": "Role"}, "isDeprecated": true, "deprecationReason": "use \"roles\""}
+    ]},
+    {"kind": "OBJECT", "name": "Team", "fields": [
+      {"name": "members", "args": [], "type": {"kind": "LIST", "ofType": {"kind": "OBJECT", "name": "Person"}}}
+    ]},
+    {"kind": "UNION", "name": "Result", "possibleTypes": [{"kind": "OBJECT", "name": "Person"}, {"kind": "OBJECT", "name": "Team"}]},
+    {"kind": "ENUM", "name": "Role", "enumValues": [
+      {"name": "ADMIN", "description": "Can do anything"},
+      {"name": "USER"},
+      {"name": "GUEST", "isDeprecated": true, "deprecationReason": "use USER"}
+    ]},
+    {"kind": "INPUT_OBJECT", "name": "PersonInput", "inputFields": [
+      {"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+      {"name": "roles", "description": "Defaults to users", "type": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "ENUM", "name": "Role"}}}, "defaultValue": "[USER]"}
+    ]}
+  ]
+}}}`
+
+const introspectionSDL = `schema {
+  query: Query
+  mutation: Mutation
+}
+
+# The cost of a field
+directive @cost(weight: Int = 1) on FIELD_DEFINITION | OBJECT
+
+# An RFC 3339 time
+scalar Time
+
+type Query {
+  search(
+    # The searched text
+    text: String!
+    first: Int = 10
+  ): [Result!]!
+  node(id: ID!): Node
+}
+
+type Mutation {
+  createPerson(input: PersonInput!): Person
+}
+
+interface Node {
+  id: ID!
+}
+
+# A person
+# of the catalog
+type Person implements Node {
+  id: ID!
+  # The full name
+  name: String
+  born: Time @deprecated
+  role: Role @deprecated(reason: "use \"roles\"")
+}
+
+type Team {
+  members: [Person]
+}
+
+union Result = Person | Team
+
+enum Role {
+  # Can do anything
+  ADMIN
+  USER
+  GUEST @deprecated(reason: "use USER")
+}
+
+input PersonInput {
+  name: String!
+  # Defaults to users
+  roles: [Role!] = [USER]
+}
+`
+
+func TestIntrospectionToSDL(t *testing.T) {
+  sdl, err := IntrospectionToSDL([]byte(introspectionJSON))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if string(sdl) != introspectionSDL {
+    t.Errorf("got the schema\n%s\nwant\n%s", sdl, introspectionSDL)
+  }
+
+  // the data of the result can be given without the response around it
+  data := strings.TrimSuffix(strings.TrimPrefix(introspectionJSON, `{"data": `), "}")
+  if sdl, err := IntrospectionToSDL([]byte(data)); err != nil || string(sdl) != introspectionSDL {
+    t.Errorf("got the schema\n%s\nand the error %v from the data of the result", sdl, err)
+  }
+}
+
+func TestParseIntrospectionJSON(t *testing.T) {
+  g := New()
+  if err := g.Parse([]byte("\n  " + introspectionJSON)); err != nil {
+    t.Fatal(err)
+  }
+  if string(g.rawSchema) != introspectionSDL {
+    t.Errorf("got the raw schema\n%s\nwant the converted SDL", g.rawSchema)
+  }
+  for _, name := range []string{"Person", "Team", "Result", "Role", "PersonInput", "Time"} {
+    if g.schema.ASTSchema().Types[name] == nil {
+      t.Errorf("type %s is missing from the parsed schema", name)
+    }
+  }
+}
+
+func TestIntrospectionToSDLErrors(t *testing.T) {
+  for name, test := range map[string]struct {
+    data string
+    want string
+  }{
+    "invalid json": {
+      data: `{"data": `,
+      want: "invalid introspection result: unexpected end of JSON input",
+    },
+    "errors": {
+      data: `{"errors": [{"message": "not allowed"}, {"message": "introspection disabled"}]}`,
+      want: "introspection failed: not allowed, introspection disabled",
+    },
+    "no schema": {
+      data: `{"data": {"me": null}}`,
+      want: "invalid introspection result: no __schema with a query type",
+    },
+    "no query type": {
+      data: `{"__schema": {"types": []}}`,
+      want: "invalid introspection result: no __schema with a query type",
+    },
+  } {
+    t.Run(name, func(t *testing.T) {
+      _, err := IntrospectionToSDL([]byte(test.data))
+      if err == nil || err.Error() != test.want {
+        t.Errorf("got the error %v, want %q", err, test.want)
+      }
+    })
+  }
+}
@@ -0,0 +1,121 @@
+package generator
+
+import (
+  "go/ast"
+  "go/parser"
+  "go/token"
+  "os"
+  "strings"
+  "testing"
+)
+
+func TestGenServerFileSubscriptions(t *testing.T) {
+  websocket := []string{"websocket.Upgrader", "func (h *httpServer) serveWebSocket(", "func (g *GqlServer) CloseWebSockets()", "func NewPubSub() *PubSub"}
+
+  // the schema without a subscription type is served over http only
+  g := newTestGenerator(t, stubsSchema)
+  src := string(g.GenServerFile())
+  for _, unwanted := range append(websocket, "gorilla/websocket") {
+    if strings.Contains(src, unwanted) {
+      t.Errorf("the server of a schema without subscriptions has %q", unwanted)
+    }
+  }
+  compileGenerated(t, g, nil)
+
+  g = newTestGenerator(t, clientSchema)
+  src = string(g.GenServerFile())
+  for _, want := range append(websocket, "srv.RegisterOnShutdown(g.CloseWebSockets)") {
+    if !strings.Contains(src, want) {
+      t.Errorf("the server of a schema with subscriptions misses %q", want)
+    }
+  }
+  compileGenerated(t, g, nil)
+}
+
+// TestGeneratedNames checks that generatedNames holds every go name of the generated files which is not derived from the schema
+func TestGeneratedNames(t *testing.T) {
+  g := newTestGenerator(t, clientSchema+"\ndirective @cost(weight: Int = 1, multipliers: [String!]) on FIELD_DEFINITION\n")
+  derived := map[string]bool{"_": true}
+  data := g.resolversData()
+  for _, typ := range data.Types {
+    for _, name := range typ.goNames() {
+      derived[name] = true
+    }
+  }
+  for _, f := range data.Args {
+    derived[f.ArgsName()] = true
+  }
+
+  // the generators share the buffer of g, which holds the last generated file
+  resolvers := g.GenSchemaResolversFile()
+  g.Reset()
+  for _, src := range [][]byte{resolvers, g.GenServerFile()} {
+    file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+    if err != nil {
+      t.Fatal(err)
+    }
+    var names []*ast.Ident
+    for _, decl := range file.Decls {
+      switch decl := decl.(type) {
+      case *ast.FuncDecl:
+        if decl.Recv == nil {
+          names = append(names, decl.Name)
+        }
+      case *ast.GenDecl:
+        for _, spec := range decl.Specs {
+          switch spec := spec.(type) {
+          case *ast.TypeSpec:
+            names = append(names, spec.Name)
+          case *ast.ValueSpec:
+            names = append(names, spec.Names...)
+          }
+        }
+      }
+    }
+    for _, name := range names {
+      if !derived[name.Name] && !generatedNames[name.Name] {
+        t.Errorf("the generated go name %s is not in generatedNames", name.Name)
+      }
+    }
+  }
+}
+
+func TestGoNameCollisions(t *testing.T) {
+  if schema := os.Getenv("GO_NAME_SCHEMA"); schema != "" {
+    newTestGenerator(t, schema).resolversData()
+    return
+  }
+
+  for name, test := range map[string]struct {
+    schema string
+    want   string
+  }{
+    "server type": {
+      schema: "type Query {\n  server: Server\n}\n\ntype Server {\n  name: String\n}\n",
+      want:   "5:6: go name Server of type Server collides with the go types of the generated resolvers and server",
+    },
+    "server constant": {
+      schema: "type Query {\n  posts: [Post]\n}\n\ntype Post {\n  text: String\n}\n",
+      want:   "5:6: go name Post of type Post collides with the go types of the generated resolvers and server",
+    },
+    "loaders": {
+      schema: "type Query {\n  loaders: [Loaders]\n}\n\nscalar Loaders\n",
+      want:   "go name Loaders of type Loaders collides with the go types of the generated resolvers and server",
+    },
+    "resolver of another type": {
+      schema: "type Query {\n  person: Person\n  find(filter: PersonResolver): Int\n}\n\ntype Person {\n  name: String\n}\n\n" +
+        "input PersonResolver {\n  name: String\n}\n",
+      want: "10:7: go name PersonResolver of type PersonResolver collides with the go types of type Person",
+    },
+    "enum constant": {
+      schema: "type Query {\n  color: Color\n  red: ColorRed\n}\n\nenum Color {\n  RED\n}\n\nscalar ColorRed\n",
+      want:   "10:8: go name ColorRed of type ColorRed collides with the go types of type Color",
+    },
+  } {
+    t.Run(name, func(t *testing.T) {
+      if out := runFailing(t, "TestGoNameCollisions", "GO_NAME_SCHEMA="+test.schema); !strings.Contains(out, test.want) {
+        t.Errorf("got %q, want it to contain %q", out, test.want)
+      }
+    })
+  }
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+  "bytes"
+  "errors"
+  "fmt"
+
+  gqlerrors "github.com/graph-gophers/graphql-go/errors"
+  gqltypes "github.com/graph-gophers/graphql-go/types"
+)
+
+// sourceMap maps the lines of the concatenated schema files, which graphql-go reports locations in, back to the files
+type sourceMap []sourceFile
+
+type sourceFile struct {
+  name string
+  // line is the line of the concatenated schema the file starts at
+  line int
+}
+
+// concatFiles concatenates files, each one starting on a new line, and maps the lines of the result to them
+func concatFiles(files []*SchemaFile) ([]byte, sourceMap) {
+  data := &bytes.Buffer{}
+  var sources sourceMap
+  line := 1
+  for _, f := range files {
+    data.WriteString("\n")
+    line++
+    sources = append(sources, sourceFile{name: f.Name, line: line})
+    data.Write(f.Content)
+    line += bytes.Count(f.Content, []byte("\n"))
+  }
+  return data.Bytes(), sources
+}
+
+// position returns the `file:line:col` position of a location of the concatenated schema
+func (m sourceMap) position(loc gqlerrors.Location) string {
+  for i := len(m) - 1; i >= 0; i-- {
+    if loc.Line >= m[i].line {
+      return fmt.Sprintf("%s:%d:%d", m[i].name, loc.Line-m[i].line+1, loc.Column)
+    }
+  }
+  return fmt.Sprintf("%d:%d", loc.Line, loc.Column)
+}
+
+// translate positions an error of graphql-go in the schema files.
+// Errors without a location are returned as they are
+func (m sourceMap) translate(err error) error {
+  qerr, ok := err.(*gqlerrors.QueryError)
+  if !ok || len(qerr.Locations) == 0 {
+    return err
+  }
+  msg := m.position(qerr.Locations[0]) + ": " + qerr.Message
+  for _, loc := range qerr.Locations[1:] {
+    msg += " (see " + m.position(loc) + ")"
+  }
+  return errors.New(msg)
+}
+
+// typePosition returns the position of the definition of a type
+func (g Generator) typePosition(name string) string {
+  return g.sources.position(typeLocation(g.schema.ASTSchema().Types[name]))
+}
+
+// fieldPosition returns the position of the definition of the field of an object or interface type
+func (g Generator) fieldPosition(f *FieldDef) string {
+  var fields gqltypes.FieldsDefinition
+  switch t := g.schema.ASTSchema().Types[f.Parent].(type) {
+  case *gqltypes.ObjectTypeDefinition:
+    fields = t.Fields
+  case *gqltypes.InterfaceTypeDefinition:
+    fields = t.Fields
+  }
+  if f.gqlField != nil {
+    if def := fields.Get(f.gqlField.Name()); def != nil {
+      return g.sources.position(def.Loc)
+    }
+  }
+  return g.typePosition(f.Parent)
+}
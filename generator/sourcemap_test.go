@@ -0,0 +1,72 @@
+package generator
+
+import (
+  "strings"
+  "testing"
+)
+
+func TestParseFilesErrors(t *testing.T) {
+  for name, test := range map[string]struct {
+    files []*SchemaFile
+    want  string
+  }{
+    "syntax error": {
+      files: []*SchemaFile{
+        {Name: "schema.graphql", Content: []byte("schema { query: Query }\ntype Query { a: Int }\n")},
+        {Name: "types.graphql", Content: []byte("type A {\n  b: Int\n  c Int\n}\n")},
+      },
+      want: "types.graphql:3:",
+    },
+    "unknown type": {
+      files: []*SchemaFile{
+        {Name: "schema.graphql", Content: []byte("schema { query: Query }\n\ntype Query {\n  a: A\n}")},
+        {Name: "types.graphql", Content: []byte("type B { b: Int }\n")},
+      },
+      want: `schema.graphql:4:`,
+    },
+    "file without a final newline": {
+      files: []*SchemaFile{
+        {Name: "a.graphql", Content: []byte("schema { query: Query }")},
+        {Name: "b.graphql", Content: []byte("type Query { a: Int }")},
+        {Name: "c.graphql", Content: []byte("\n\ntype C { b Int }\n")},
+      },
+      want: "c.graphql:3:",
+    },
+  } {
+    t.Run(name, func(t *testing.T) {
+      err := New().ParseFiles(test.files)
+      if err == nil || !strings.HasPrefix(err.Error(), test.want) {
+        t.Errorf("got the error %v, want it to start with %q", err, test.want)
+      }
+    })
+  }
+}
+
+func TestPositions(t *testing.T) {
+  g := New()
+  err := g.ParseFiles([]*SchemaFile{
+    {Name: "schema.graphql", Content: []byte("schema {\n  query: Query\n}\n")},
+    {Name: "query.graphql", Content: []byte("type Query {\n  person: Person\n}\n")},
+    {Name: "person.graphql", Content: []byte("\n# a person\ntype Person {\n  id: ID!\n    name: String\n}\n")},
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  if got, want := g.typePosition("Query"), "query.graphql:1:6"; got != want {
+    t.Errorf("got the position %s of Query, want %s", got, want)
+  }
+  if got, want := g.typePosition("Person"), "person.graphql:3:6"; got != want {
+    t.Errorf("got the position %s of Person, want %s", got, want)
+  }
+
+  var name *FieldDef
+  for _, typ := range g.types() {
+    if *typ.Name() == "Person" {
+      name = NewType(typ, nil).Fields[1]
+    }
+  }
+  if got, want := g.fieldPosition(name), "person.graphql:5:5"; got != want {
+    t.Errorf("got the position %s of Person.name, want %s", got, want)
+  }
+}
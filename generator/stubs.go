@@ -0,0 +1,127 @@
+package generator
+
+import (
+  "go/ast"
+  "go/format"
+  "go/parser"
+  "go/token"
+  "os"
+  "strings"
+)
+
+// GenStubsFile generates the resolver methods which are left to the user (the ones of the fields with arguments)
+// with a body panicking until they are implemented.
+// The methods already declared by the package in dir are skipped. When src, the current content of the stub file,
+// is not empty the new stubs are appended to it, so the methods implemented in place of the stubs are left alone.
+// It returns nil when there is no stub to add
+func (g Generator) GenStubsFile(dir string, src []byte) []byte {
+
+  declared := g.declaredMethods(dir)
+
+  data := struct {
+    New    bool
+    Fields []*FieldDef
+  }{
+    New: len(src) == 0,
+  }
+
+  for _, t := range g.resolversData().Types {
+    if t.Kind != gqlOBJECT {
+      continue
+    }
+    for _, f := range t.Fields {
+      if len(f.Args) > 0 && !declared[f.Parent+"Resolver."+f.Name] {
+        data.Fields = append(data.Fields, f)
+      }
+    }
+  }
+
+  if len(data.Fields) == 0 {
+    return nil
+  }
+
+  g.execute("stubs.tmpl", data)
+  if data.New {
+    return g.genFile()
+  }
+  return g.appendFile(src)
+}
+
+// declaredMethods returns the methods declared by the hand-written go files of the package in dir
+// as `Receiver.Method`, the generated files are skipped
+func (g Generator) declaredMethods(dir string) map[string]bool {
+  methods := map[string]bool{}
+
+  filter := func(fi os.FileInfo) bool {
+    return !strings.HasSuffix(fi.Name(), ".gql.go") && !strings.HasSuffix(fi.Name(), "_test.go")
+  }
+  pkgs, err := parser.ParseDir(token.NewFileSet(), dir, filter, 0)
+  if err != nil {
+    if os.IsNotExist(err) {
+      return methods
+    }
+    g.Error(err, "unable to parse the package in", dir)
+  }
+
+  for _, pkg := range pkgs {
+    for _, file := range pkg.Files {
+      for _, decl := range file.Decls {
+        fn, ok := decl.(*ast.FuncDecl)
+        if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+          continue
+        }
+        recv := fn.Recv.List[0].Type
+        if star, ok := recv.(*ast.StarExpr); ok {
+          recv = star.X
+        }
+        if ident, ok := recv.(*ast.Ident); ok {
+          methods[ident.Name+"."+fn.Name.Name] = true
+        }
+      }
+    }
+  }
+  return methods
+}
+
+// appendFile appends the generated output to the existing file src,
+// adding the imports the output requires to it, and gofmts the result
+func (g Generator) appendFile(src []byte) []byte {
+  body := append([]byte(nil), g.Bytes()...)
+  g.Reset()
+
+  out := append(append([]byte(nil), src...), '\n')
+  out = append(out, body...)
+
+  fset := token.NewFileSet()
+  file, err := parser.ParseFile(fset, "", out, 0)
+  if err != nil {
+    g.Error(err, "unable to parse the stub file")
+  }
+
+  std, other := g.missingImports(file)
+  if imports := append(std, other...); len(imports) > 0 {
+    // add the imports to the import block of the file or to a new one after the package clause
+    var at token.Pos
+    var specs string
+    for _, decl := range file.Decls {
+      if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT && gen.Lparen.IsValid() {
+        at = gen.Rparen
+        specs = strings.Join(imports, "\n") + "\n"
+        break
+      }
+    }
+    if at == token.NoPos {
+      at = file.Name.End()
+      specs = "\n\n" + importBlock(std, other)
+    }
+
+    offset := fset.Position(at).Offset
+    out = append(out[:offset:offset], append([]byte(specs), out[offset:]...)...)
+  }
+
+  formatted, err := format.Source(out)
+  if err != nil {
+    g.Error(err, "bad go source code was generated")
+  }
+  return formatted
+}
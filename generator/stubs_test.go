@@ -0,0 +1,140 @@
+package generator
+
+import (
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+const stubsSchema = `
+schema {
+  query: Query
+}
+
+type Query {
+  person(id: ID!): Person
+}
+
+type Person {
+  id: ID!
+  name: String!
+  friends(first: Int): [Person!]!
+  nickname(lang: String): String
+}
+`
+
+func newTestGenerator(t *testing.T, schema string) *Generator {
+  g := New().SetPkgName("api")
+  if err := g.Parse([]byte(schema)); err != nil {
+    t.Fatal(err)
+  }
+  return g
+}
+
+func TestGenStubsFile(t *testing.T) {
+  g := newTestGenerator(t, stubsSchema)
+  dir := t.TempDir()
+
+  src := string(g.GenStubsFile(dir, nil))
+  for _, want := range []string{
+    "package api",
+    "func (r PersonResolver) Friends(ctx context.Context, args PersonFriendsArgs) ([]PersonResolver, error) {",
+    "func (r PersonResolver) Nickname(ctx context.Context, args PersonNicknameArgs) (*string, error) {",
+    `panic("not implemented")`,
+  } {
+    if !strings.Contains(src, want) {
+      t.Errorf("the stubs lack %q:\n%s", want, src)
+    }
+  }
+  // the fields without arguments are generated, the root fields are implemented by the root resolver
+  for _, unwanted := range []string{"Name(", "Person(ctx"} {
+    if strings.Contains(src, unwanted) {
+      t.Errorf("the stubs have %q:\n%s", unwanted, src)
+    }
+  }
+}
+
+func TestGenStubsFileSkipsDeclaredMethods(t *testing.T) {
+  g := newTestGenerator(t, stubsSchema)
+  dir := t.TempDir()
+  impl := `package api
+
+import "context"
+
+func (r PersonResolver) Friends(ctx context.Context, args PersonFriendsArgs) ([]PersonResolver, error) {
+  return nil, nil
+}
+`
+  if err := ioutil.WriteFile(filepath.Join(dir, "person.go"), []byte(impl), 0644); err != nil {
+    t.Fatal(err)
+  }
+  // the generated files are not hand-written
+  if err := ioutil.WriteFile(filepath.Join(dir, "api.gql.go"), []byte("package api\n\nfunc (r PersonResolver) Nickname() {}\n"), 0644); err != nil {
+    t.Fatal(err)
+  }
+
+  src := string(g.GenStubsFile(dir, nil))
+  if strings.Contains(src, "Friends(") || !strings.Contains(src, "Nickname(") {
+    t.Errorf("the stubs are not the ones of the undeclared methods:\n%s", src)
+  }
+
+  if err := ioutil.WriteFile(filepath.Join(dir, "nickname.go"), []byte("package api\n\nfunc (r *PersonResolver) Nickname() {}\n"), 0644); err != nil {
+    t.Fatal(err)
+  }
+  if src := g.GenStubsFile(dir, nil); src != nil {
+    t.Errorf("stubs are generated for the declared methods:\n%s", src)
+  }
+}
+
+func TestGenStubsFileAppends(t *testing.T) {
+  g := newTestGenerator(t, stubsSchema)
+  dir := t.TempDir()
+  existing := `package api
+
+// Friends is implemented in place of its stub
+func (r PersonResolver) Friends() []PersonResolver {
+  return nil
+}
+`
+  if err := ioutil.WriteFile(filepath.Join(dir, "api-extra.go"), []byte(existing), 0644); err != nil {
+    t.Fatal(err)
+  }
+
+  src := string(g.GenStubsFile(dir, []byte(existing)))
+  if !strings.Contains(src, "// Friends is implemented in place of its stub\nfunc (r PersonResolver) Friends() []PersonResolver {") {
+    t.Errorf("the existing content is modified:\n%s", src)
+  }
+  if strings.Count(src, "Friends(") != 1 {
+    t.Errorf("the implemented method gets a stub:\n%s", src)
+  }
+  if !strings.Contains(src, "func (r PersonResolver) Nickname(ctx context.Context, args PersonNicknameArgs) (*string, error) {") {
+    t.Errorf("the new stub is not appended:\n%s", src)
+  }
+  if !strings.Contains(src, "import (\n\t\"context\"\n)") {
+    t.Errorf("the imports of the stubs are not added:\n%s", src)
+  }
+}
+
+func TestFieldResolversAssertion(t *testing.T) {
+  g := newTestGenerator(t, stubsSchema)
+  src := string(g.GenSchemaResolversFile())
+  want := "type PersonFieldResolvers interface {\n" +
+    "\tFriends(ctx context.Context, args PersonFriendsArgs) ([]PersonResolver, error)\n" +
+    "\tNickname(ctx context.Context, args PersonNicknameArgs) (*string, error)\n" +
+    "}\n\nvar _ PersonFieldResolvers = PersonResolver{}"
+  if !strings.Contains(src, want) {
+    t.Errorf("generated resolvers miss\n%s", want)
+  }
+
+  // the package does not compile until the stubs implement the resolvers
+  dir := genPackage(t, g, nil)
+  if err := os.Remove(filepath.Join(dir, "api-extra.go")); err != nil {
+    t.Fatal(err)
+  }
+  out, err := vetPackage(dir)
+  if err == nil || !strings.Contains(string(out), "PersonResolver does not implement PersonFieldResolvers") {
+    t.Errorf("got %v\n%s\nwant the resolvers to be missing", err, out)
+  }
+}
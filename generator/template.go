@@ -0,0 +1,113 @@
+package generator
+
+import (
+  "embed"
+  "os"
+  "path/filepath"
+  "strings"
+  "text/template"
+)
+
+// templateFS holds the default templates of the generated code.
+// A template named `NAME.tmpl` can be overridden by a file with the same name in Generator.TemplateDir
+//
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// funcs returns the functions available in the templates
+func (g Generator) funcs() template.FuncMap {
+  return template.FuncMap{
+    "comment": comment,
+    "ctx": func() bool {
+      return g.ContextResolvers
+    },
+    "lowerFirst":  lowerFirst,
+    "params":      g.params,
+    "namedParams": g.namedParams,
+    "callArgs":    g.callArgs,
+    "results":     g.results,
+  }
+}
+
+// comment returns a go comment for the description of a graphql type or field
+func comment(name, desc string) string {
+  if desc == "" {
+    return ""
+  }
+  return "// " + name + " " + strings.Replace(desc, "\n", "\n// ", -1)
+}
+
+// params returns the parameters of the resolver method of a field
+func (g Generator) params(f *FieldDef) string {
+  var params []string
+  if g.ContextResolvers {
+    params = append(params, "ctx context.Context")
+    if len(f.Args) > 0 {
+      params = append(params, "args "+f.ArgsName())
+    }
+  } else if len(f.Args) > 0 {
+    params = append(params, f.ArgsName())
+  }
+  return strings.Join(params, ", ")
+}
+
+// namedParams returns the parameters of the resolver method of a field, naming them in legacy mode too
+func (g Generator) namedParams(f *FieldDef) string {
+  if !g.ContextResolvers && len(f.Args) > 0 {
+    return "args " + f.ArgsName()
+  }
+  return g.params(f)
+}
+
+// callArgs returns the arguments passing the namedParams of a field on to another resolver method
+func (g Generator) callArgs(f *FieldDef) string {
+  var args []string
+  if g.ContextResolvers {
+    args = append(args, "ctx")
+  }
+  if len(f.Args) > 0 {
+    args = append(args, "args")
+  }
+  return strings.Join(args, ", ")
+}
+
+// results returns the results of a resolver method returning the given type
+func (g Generator) results(typ string) string {
+  if g.ContextResolvers {
+    return "(" + typ + ", error)"
+  }
+  return typ
+}
+
+// templates parses the embedded templates followed by the ones of the template dir
+func (g Generator) templates() (*template.Template, error) {
+  tmpl, err := template.New("").Funcs(g.funcs()).ParseFS(templateFS, "templates/*.tmpl")
+  if err != nil {
+    return nil, err
+  }
+
+  if g.TemplateDir == "" {
+    return tmpl, nil
+  }
+
+  if _, err := os.Stat(g.TemplateDir); err != nil {
+    return nil, err
+  }
+
+  files, err := filepath.Glob(filepath.Join(g.TemplateDir, "*.tmpl"))
+  if err != nil || len(files) == 0 {
+    return tmpl, err
+  }
+  return tmpl.ParseFiles(files...)
+}
+
+// execute writes the output of the named template to the generated output
+func (g Generator) execute(name string, data interface{}) {
+  tmpl, err := g.templates()
+  if err != nil {
+    g.Error(err, "unable to parse templates")
+  }
+  if err := tmpl.ExecuteTemplate(g, name, data); err != nil {
+    g.Error(err, "unable to execute template", name)
+  }
+}
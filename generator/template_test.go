@@ -0,0 +1,90 @@
+package generator
+
+import (
+  "fmt"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+func TestTemplateDir(t *testing.T) {
+  dir := t.TempDir()
+  // the templates of the dir replace the embedded ones of the same name, including the ones included by others
+  overrides := map[string]string{
+    "field_resolver.tmpl": "// custom resolver of {{.Parent}}.{{.Name}}\n",
+    "scalar.tmpl":         "type {{.Name}} = string\n",
+    "notes.txt":           "not a template",
+  }
+  for name, src := range overrides {
+    if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+      t.Fatal(err)
+    }
+  }
+
+  g := newTestGenerator(t, fmt.Sprintf(scalarSchema, "Color")).SetTemplateDir(dir)
+  src := string(g.GenSchemaResolversFile())
+  for _, want := range []string{
+    "// custom resolver of Item.Value\n",
+    "type Color = string\n",
+    // the other templates are the embedded ones
+    "type ItemResolver struct {\n\tR *Item\n}",
+  } {
+    if !strings.Contains(src, want) {
+      t.Errorf("generated resolvers miss\n%s", want)
+    }
+  }
+  if strings.Contains(src, "func (r ItemResolver) Value(") {
+    t.Error("generated resolvers have the embedded field resolver")
+  }
+}
+
+func TestTemplateDirErrors(t *testing.T) {
+  if dir := os.Getenv("TEMPLATE_DIR"); dir != "" {
+    newTestGenerator(t, stubsSchema).SetTemplateDir(dir).GenSchemaResolversFile()
+    return
+  }
+
+  dir := t.TempDir()
+  if err := os.WriteFile(filepath.Join(dir, "object.tmpl"), []byte("{{.Name"), 0644); err != nil {
+    t.Fatal(err)
+  }
+  for name, test := range map[string]struct {
+    dir  string
+    want string
+  }{
+    "missing dir":      {dir: filepath.Join(dir, "missing"), want: "unable to parse templates:stat " + filepath.Join(dir, "missing")},
+    "invalid template": {dir: dir, want: "unable to parse templates:template: object.tmpl:1: unclosed action"},
+  } {
+    t.Run(name, func(t *testing.T) {
+      if out := runFailing(t, "TestTemplateDirErrors", "TEMPLATE_DIR="+test.dir); !strings.Contains(out, test.want) {
+        t.Errorf("got %q, want it to contain %q", out, test.want)
+      }
+    })
+  }
+}
+
+func TestLegacyResolvers(t *testing.T) {
+  g := newTestGenerator(t, stubsSchema).SetContextResolvers(false)
+
+  src := string(g.GenSchemaResolversFile())
+  for _, want := range []string{
+    "Person(QueryPersonArgs) *PersonResolver\n",
+    "Friends(PersonFriendsArgs) []PersonResolver\n",
+    "func (r PersonResolver) Name() string {\n\treturn r.R.Name\n}",
+  } {
+    if !strings.Contains(src, want) {
+      t.Errorf("generated resolvers miss\n%s", want)
+    }
+  }
+  if strings.Contains(src, "ctx context.Context, args") || strings.Contains(src, "Resolver, error)") {
+    t.Error("the legacy resolvers take a context or return an error")
+  }
+
+  g.Reset()
+  stubs := string(g.GenStubsFile(t.TempDir(), nil))
+  if want := "func (r PersonResolver) Friends(args PersonFriendsArgs) []PersonResolver {"; !strings.Contains(stubs, want) {
+    t.Errorf("the stubs miss\n%s\n%s", want, stubs)
+  }
+  compileGenerated(t, g, nil)
+}
@@ -0,0 +1,461 @@
+// Package gqlquery parses the executable documents of GraphQL, for the generator and the generated servers.
+// The documents are expected to be validated by graphql-go, the parser only reports syntax errors.
+//
+// The generated servers import the package at runtime, so its exported API is stable: it only changes
+// in backward compatible ways, and a server generated by an older version of the generator keeps compiling
+package gqlquery
+
+import (
+  "fmt"
+  "strconv"
+  "strings"
+  "text/scanner"
+
+  gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// Document is an executable document with its operations and fragments
+type Document struct {
+  Operations []*Operation
+  Fragments  map[string]*Fragment
+}
+
+// Operation returns the operation of the name, or the only operation of the document when the name is empty
+func (doc *Document) Operation(name string) *Operation {
+  if name == "" {
+    if len(doc.Operations) == 1 {
+      return doc.Operations[0]
+    }
+    return nil
+  }
+  for _, op := range doc.Operations {
+    if op.Name == name {
+      return op
+    }
+  }
+  return nil
+}
+
+type Operation struct {
+  // Kind is query, mutation or subscription
+  Kind string
+  // Name is empty for an anonymous operation
+  Name       string
+  Vars       []*VariableDefinition
+  Selections []Selection
+  // Text is the source of the definition
+  Text string
+  Loc  gqlerrors.Location
+}
+
+// Var returns the definition of the variable of the name, or nil when the operation does not define it
+func (op *Operation) Var(name string) *VariableDefinition {
+  for _, v := range op.Vars {
+    if v.Name == name {
+      return v
+    }
+  }
+  return nil
+}
+
+type VariableDefinition struct {
+  Name string
+  Type *TypeRef
+  // Default is the default value of the variable, nil when it has none
+  Default interface{}
+}
+
+// TypeRef is the type of a variable i.e., `[ID!]!`
+type TypeRef struct {
+  Name    string
+  NonNull bool
+  // Elem is the type of the items of a list type
+  Elem *TypeRef
+}
+
+type Fragment struct {
+  Name       string
+  On         string
+  Selections []Selection
+  Text       string
+}
+
+// Selection is a *Field, *FragmentSpread or *InlineFragment
+type Selection interface{}
+
+type Field struct {
+  Alias string
+  Name  string
+  // Arguments are the values of the arguments of the field by name
+  Arguments  map[string]interface{}
+  Selections []Selection
+  // Conditional is set when the field has a @skip or @include directive
+  Conditional bool
+  Loc         gqlerrors.Location
+}
+
+// Key returns the name of the field in the response
+func (f *Field) Key() string {
+  if f.Alias != "" {
+    return f.Alias
+  }
+  return f.Name
+}
+
+type FragmentSpread struct {
+  Name        string
+  Conditional bool
+}
+
+type InlineFragment struct {
+  // On is empty when the fragment has no type condition
+  On          string
+  Selections  []Selection
+  Conditional bool
+}
+
+// The values of the arguments and of the variable defaults are an int, float64, string, bool, Enum, Variable,
+// []interface{} for a list, map[string]interface{} for an input object, or nil for null
+
+// Enum is an enum value
+type Enum string
+
+// Variable is a variable used as a value, by its name
+type Variable string
+
+type syntaxError string
+
+// parser is a recursive descent parser of executable documents
+type parser struct {
+  sc  scanner.Scanner
+  src string
+  tok rune
+  // pos is the position of the current token
+  pos scanner.Position
+  // end is the offset of the end of the previous token
+  end int
+  // str is the value of the current token when it is a string
+  str string
+}
+
+// Parse parses an executable document, a syntax error is a *gqlerrors.QueryError with its location
+func Parse(src string) (doc *Document, err error) {
+  p := &parser{src: src}
+  p.sc.Init(strings.NewReader(src))
+  p.sc.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanFloats | scanner.ScanStrings
+  p.sc.Error = func(s *scanner.Scanner, msg string) {}
+
+  defer func() {
+    if r := recover(); r != nil {
+      msg, ok := r.(syntaxError)
+      if !ok {
+        panic(r)
+      }
+      err = &gqlerrors.QueryError{
+        Message:   string(msg),
+        Locations: []gqlerrors.Location{location(p.pos)},
+      }
+    }
+  }()
+
+  doc = &Document{Fragments: map[string]*Fragment{}}
+  p.next()
+  for p.tok != scanner.EOF {
+    start := p.pos
+    switch {
+    case p.tok == '{':
+      op := &Operation{Kind: "query", Loc: location(start)}
+      op.Selections = p.selectionSet()
+      op.Text = p.text(start)
+      doc.Operations = append(doc.Operations, op)
+    case p.keyword("fragment"):
+      p.next()
+      f := &Fragment{Name: p.name()}
+      p.expectKeyword("on")
+      f.On = p.name()
+      p.directives()
+      f.Selections = p.selectionSet()
+      f.Text = p.text(start)
+      doc.Fragments[f.Name] = f
+    case p.keyword("query"), p.keyword("mutation"), p.keyword("subscription"):
+      op := &Operation{
+        Kind: p.sc.TokenText(),
+        Loc:  location(start),
+      }
+      p.next()
+      if p.tok == scanner.Ident {
+        op.Name = p.name()
+      }
+      if p.tok == '(' {
+        op.Vars = p.variables()
+      }
+      p.directives()
+      op.Selections = p.selectionSet()
+      op.Text = p.text(start)
+      doc.Operations = append(doc.Operations, op)
+    default:
+      p.fail(fmt.Sprintf("unexpected %q, expecting an operation or fragment", p.sc.TokenText()))
+    }
+  }
+  return doc, nil
+}
+
+// next moves to the next token skipping comments and commas
+func (p *parser) next() {
+  p.end = p.sc.Pos().Offset
+  for {
+    p.tok = p.sc.Scan()
+    p.pos = p.sc.Position
+    switch p.tok {
+    case ',':
+      continue
+    case '#':
+      for ch := p.sc.Peek(); ch != '\n' && ch != scanner.EOF; ch = p.sc.Peek() {
+        p.sc.Next()
+      }
+      continue
+    case scanner.String:
+      // a block string is scanned as an empty string followed by a quote
+      if p.sc.TokenText() == `""` && p.sc.Peek() == '"' {
+        p.blockString()
+      } else if s, err := strconv.Unquote(p.sc.TokenText()); err == nil {
+        p.str = s
+      } else {
+        p.str = strings.Trim(p.sc.TokenText(), `"`)
+      }
+    }
+    return
+  }
+}
+
+// blockString scans the rest of a block string, its value is kept as it is written
+func (p *parser) blockString() {
+  p.sc.Next()
+  quotes := 0
+  for quotes < 3 {
+    switch ch := p.sc.Next(); ch {
+    case scanner.EOF:
+      p.fail("unterminated block string")
+    case '"':
+      quotes++
+    case '\\':
+      p.sc.Next()
+      quotes = 0
+    default:
+      quotes = 0
+    }
+  }
+  p.str = p.src[p.pos.Offset+3 : p.sc.Pos().Offset-3]
+}
+
+// text returns the source from start to the end of the previous token
+func (p *parser) text(start scanner.Position) string {
+  return p.src[start.Offset:p.end]
+}
+
+func (p *parser) fail(msg string) {
+  panic(syntaxError(msg))
+}
+
+func (p *parser) keyword(name string) bool {
+  return p.tok == scanner.Ident && p.sc.TokenText() == name
+}
+
+func (p *parser) expectKeyword(name string) {
+  if !p.keyword(name) {
+    p.fail(fmt.Sprintf("unexpected %q, expecting %q", p.sc.TokenText(), name))
+  }
+  p.next()
+}
+
+func (p *parser) expect(tok rune) {
+  if p.tok != tok {
+    p.fail(fmt.Sprintf("unexpected %q, expecting %s", p.sc.TokenText(), scanner.TokenString(tok)))
+  }
+  p.next()
+}
+
+func (p *parser) name() string {
+  name := p.sc.TokenText()
+  p.expect(scanner.Ident)
+  return name
+}
+
+func (p *parser) variables() []*VariableDefinition {
+  var vars []*VariableDefinition
+  p.expect('(')
+  for p.tok != ')' {
+    p.expect('$')
+    v := &VariableDefinition{Name: p.name()}
+    p.expect(':')
+    v.Type = p.typeRef()
+    if p.tok == '=' {
+      p.next()
+      v.Default = p.value()
+    }
+    p.directives()
+    vars = append(vars, v)
+  }
+  p.expect(')')
+  return vars
+}
+
+func (p *parser) typeRef() *TypeRef {
+  t := &TypeRef{}
+  if p.tok == '[' {
+    p.next()
+    t.Elem = p.typeRef()
+    p.expect(']')
+  } else {
+    t.Name = p.name()
+  }
+  if p.tok == '!' {
+    t.NonNull = true
+    p.next()
+  }
+  return t
+}
+
+// directives skips the directives and reports whether one of them is @skip or @include
+func (p *parser) directives() bool {
+  conditional := false
+  for p.tok == '@' {
+    p.next()
+    name := p.name()
+    conditional = conditional || name == "skip" || name == "include"
+    if p.tok == '(' {
+      p.arguments()
+    }
+  }
+  return conditional
+}
+
+// arguments returns the values of the arguments of a field or directive by name
+func (p *parser) arguments() map[string]interface{} {
+  args := map[string]interface{}{}
+  p.expect('(')
+  for p.tok != ')' {
+    name := p.name()
+    p.expect(':')
+    args[name] = p.value()
+  }
+  p.expect(')')
+  return args
+}
+
+func (p *parser) value() interface{} {
+  switch p.tok {
+  case '$':
+    p.next()
+    return Variable(p.name())
+  case '-':
+    p.next()
+    switch v := p.value().(type) {
+    case int:
+      return -v
+    case float64:
+      return -v
+    }
+    p.fail("unexpected '-', expecting a number")
+  case scanner.Int:
+    text := p.sc.TokenText()
+    p.next()
+    if n, err := strconv.Atoi(text); err == nil {
+      return n
+    }
+    f, _ := strconv.ParseFloat(text, 64)
+    return f
+  case scanner.Float:
+    f, _ := strconv.ParseFloat(p.sc.TokenText(), 64)
+    p.next()
+    return f
+  case scanner.String:
+    s := p.str
+    p.next()
+    return s
+  case scanner.Ident:
+    name := p.sc.TokenText()
+    p.next()
+    switch name {
+    case "true", "false":
+      return name == "true"
+    case "null":
+      return nil
+    }
+    return Enum(name)
+  case '[':
+    p.next()
+    list := []interface{}{}
+    for p.tok != ']' {
+      list = append(list, p.value())
+    }
+    p.next()
+    return list
+  case '{':
+    p.next()
+    obj := map[string]interface{}{}
+    for p.tok != '}' {
+      name := p.name()
+      p.expect(':')
+      obj[name] = p.value()
+    }
+    p.next()
+    return obj
+  }
+  p.fail(fmt.Sprintf("unexpected %q, expecting a value", p.sc.TokenText()))
+  return nil
+}
+
+func (p *parser) selectionSet() []Selection {
+  var sels []Selection
+  p.expect('{')
+  for p.tok != '}' {
+    sels = append(sels, p.selection())
+  }
+  p.expect('}')
+  return sels
+}
+
+func (p *parser) selection() Selection {
+  if p.tok != '.' {
+    f := &Field{Loc: location(p.pos)}
+    f.Name = p.name()
+    if p.tok == ':' {
+      p.next()
+      f.Alias = f.Name
+      f.Name = p.name()
+    }
+    if p.tok == '(' {
+      f.Arguments = p.arguments()
+    }
+    f.Conditional = p.directives()
+    if p.tok == '{' {
+      f.Selections = p.selectionSet()
+    }
+    return f
+  }
+
+  // the scanner returns the spread operator as three dots
+  for i := 0; i < 3; i++ {
+    p.expect('.')
+  }
+
+  if p.tok == scanner.Ident && !p.keyword("on") {
+    spread := &FragmentSpread{Name: p.name()}
+    spread.Conditional = p.directives()
+    return spread
+  }
+
+  inline := &InlineFragment{}
+  if p.keyword("on") {
+    p.next()
+    inline.On = p.name()
+  }
+  inline.Conditional = p.directives()
+  inline.Selections = p.selectionSet()
+  return inline
+}
+
+func location(pos scanner.Position) gqlerrors.Location {
+  return gqlerrors.Location{Line: pos.Line, Column: pos.Column}
+}
@@ -0,0 +1,139 @@
+package gqlquery
+
+import (
+  "reflect"
+  "testing"
+
+  gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+func TestParseOperations(t *testing.T) {
+  src := `# the people
+query People($first: Int = 10, $ids: [ID!]!) @live {
+  people: search(ids: $ids, first: $first) {
+    ...PersonFields @include(if: true)
+    ... on Person { email }
+    ... { id }
+  }
+}
+
+mutation { createPerson(person: {name: "Rey", tags: ["a", "b"]}) { id } }
+
+fragment PersonFields on Person { name, friends(first: -2) { id } }
+`
+  doc, err := Parse(src)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(doc.Operations) != 2 || len(doc.Fragments) != 1 {
+    t.Fatalf("parsed %d operations and %d fragments", len(doc.Operations), len(doc.Fragments))
+  }
+
+  people := doc.Operation("People")
+  if people == nil || people.Kind != "query" || people.Loc != (gqlerrors.Location{Line: 2, Column: 1}) {
+    t.Fatalf("People is %+v", people)
+  }
+  if v := people.Var("first"); v == nil || v.Default != 10 || v.Type.Name != "Int" || v.Type.NonNull {
+    t.Errorf("$first is %+v", v)
+  }
+  if v := people.Var("ids"); v == nil || v.Default != nil || !v.Type.NonNull || v.Type.Elem == nil || v.Type.Elem.Name != "ID" || !v.Type.Elem.NonNull {
+    t.Errorf("$ids is %+v", v)
+  }
+  if people.Text[len(people.Text)-1] != '}' || people.Text[:13] != "query People(" {
+    t.Errorf("the text of People is %q", people.Text)
+  }
+
+  search := people.Selections[0].(*Field)
+  if search.Key() != "people" || search.Name != "search" {
+    t.Errorf("the field is %+v", search)
+  }
+  wantArgs := map[string]interface{}{"ids": Variable("ids"), "first": Variable("first")}
+  if !reflect.DeepEqual(search.Arguments, wantArgs) {
+    t.Errorf("the arguments are %#v, want %#v", search.Arguments, wantArgs)
+  }
+  if spread := search.Selections[0].(*FragmentSpread); spread.Name != "PersonFields" || !spread.Conditional {
+    t.Errorf("the spread is %+v", spread)
+  }
+  if inline := search.Selections[1].(*InlineFragment); inline.On != "Person" || inline.Conditional {
+    t.Errorf("the inline fragment is %+v", inline)
+  }
+  if inline := search.Selections[2].(*InlineFragment); inline.On != "" || len(inline.Selections) != 1 {
+    t.Errorf("the inline fragment is %+v", inline)
+  }
+
+  create := doc.Operation("")
+  if create != nil {
+    t.Errorf("the empty name selected %+v among several operations", create)
+  }
+  create = doc.Operations[1]
+  wantArgs = map[string]interface{}{"person": map[string]interface{}{"name": "Rey", "tags": []interface{}{"a", "b"}}}
+  if f := create.Selections[0].(*Field); create.Kind != "mutation" || create.Name != "" || !reflect.DeepEqual(f.Arguments, wantArgs) {
+    t.Errorf("the mutation is %+v with the arguments %#v", create, f.Arguments)
+  }
+
+  frag := doc.Fragments["PersonFields"]
+  friends := frag.Selections[1].(*Field)
+  if frag.On != "Person" || friends.Arguments["first"] != -2 {
+    t.Errorf("the fragment is %+v with the friends %+v", frag, friends)
+  }
+}
+
+func TestParseShorthand(t *testing.T) {
+  doc, err := Parse(`{ person(id: "1") { name } }`)
+  if err != nil {
+    t.Fatal(err)
+  }
+  op := doc.Operation("")
+  if op == nil || op.Kind != "query" || op.Name != "" || len(op.Selections) != 1 {
+    t.Errorf("the operation is %+v", op)
+  }
+}
+
+func TestParseValues(t *testing.T) {
+  doc, err := Parse(`{ f(int: 3, float: -1.5, str: "a\"bé", block: """x "quoted" y""", yes: true, no: false, none: null, enum: RED, list: [1, [2]], obj: {a: {b: $v}}) }`)
+  if err != nil {
+    t.Fatal(err)
+  }
+  want := map[string]interface{}{
+    "int":   3,
+    "float": -1.5,
+    "str":   "a\"bé",
+    "block": `x "quoted" y`,
+    "yes":   true,
+    "no":    false,
+    "none":  nil,
+    "enum":  Enum("RED"),
+    "list":  []interface{}{1, []interface{}{2}},
+    "obj":   map[string]interface{}{"a": map[string]interface{}{"b": Variable("v")}},
+  }
+  args := doc.Operations[0].Selections[0].(*Field).Arguments
+  if !reflect.DeepEqual(args, want) {
+    t.Errorf("the arguments are %#v, want %#v", args, want)
+  }
+}
+
+func TestParseSyntaxError(t *testing.T) {
+  for src, want := range map[string]*gqlerrors.QueryError{
+    "query {\n  person(id: ) { name } }": {
+      Message:   `unexpected ")", expecting a value`,
+      Locations: []gqlerrors.Location{{Line: 2, Column: 14}},
+    },
+    "fragment F Person { id }": {
+      Message:   `unexpected "Person", expecting "on"`,
+      Locations: []gqlerrors.Location{{Line: 1, Column: 12}},
+    },
+    `{ f(s: """never closed) }`: {
+      Message:   "unterminated block string",
+      Locations: []gqlerrors.Location{{Line: 1, Column: 8}},
+    },
+    "type Person { id: ID }": {
+      Message:   `unexpected "type", expecting an operation or fragment`,
+      Locations: []gqlerrors.Location{{Line: 1, Column: 1}},
+    },
+  } {
+    _, err := Parse(src)
+    if !reflect.DeepEqual(err, want) {
+      t.Errorf("parsing %q returned %#v, want %#v", src, err, want)
+    }
+  }
+}
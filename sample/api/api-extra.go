@@ -1,11 +1,13 @@
 package api
 
+import "context"
+
 /**
  * Manual implementation of resolver functions that require
  * handling of complicated logic/filtering
  */
 
-func (r PersonResolver) Friends(args FriendsRequest) []*PersonResolver {
+func (r PersonResolver) Friends(ctx context.Context, args PersonFriendsArgs) ([]*PersonResolver, error) {
 
   from := 0
   // In a real app, this not a good way to find `from`
@@ -18,12 +20,13 @@ func (r PersonResolver) Friends(args FriendsRequest) []*PersonResolver {
     }
   }
 
-  to := len(r.R.Friends)
-  if args.First != nil {
-    to = from + int(*args.First)
-    if to > len(r.R.Friends) {
-      to = len(r.R.Friends)
-    }
+  // first defaults to 10 in the schema, a negative first returns no friends
+  to := from + int(args.First)
+  if to > len(r.R.Friends) {
+    to = len(r.R.Friends)
+  }
+  if to < from {
+    to = from
   }
 
   friends := make([]*PersonResolver, to-from)
@@ -31,5 +34,5 @@ func (r PersonResolver) Friends(args FriendsRequest) []*PersonResolver {
     friends[i] = &PersonResolver{r.R.Friends[from+i]}
   }
 
-  return friends
+  return friends, nil
 }
@@ -1,155 +1,762 @@
 package api
 
 import (
-  "github.com/graph-gophers/graphql-go"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dealtap/graphql-gen-go/gqlquery"
+	graphql "github.com/graph-gophers/graphql-go"
 )
 
-type File struct {
-  ID     string
-  Name   string
-  Folder Folder
+type PersonInput struct {
+	Name  string
+	Email string
 }
 
-type FileResolver struct {
-  R *File
+type FolderInput struct {
+	ID   *string
+	Name string
 }
 
-func (r FileResolver) ID() graphql.ID {
-  id := graphql.ID(r.R.ID)
-  return id
+type FileInput struct {
+	Name string
 }
 
-func (r FileResolver) Name() string {
-  return r.R.Name
+// Node is implemented by the models of the types implementing the Node interface
+type Node interface {
+	isNode()
 }
 
-func (r FileResolver) Folder() FolderResolver {
-  return FolderResolver{&r.R.Folder}
+func (*Person) isNode() {}
+
+func (*Folder) isNode() {}
+
+func (*File) isNode() {}
+
+// nodeResolver is implemented by the resolvers of the types implementing the Node interface
+type nodeResolver interface {
+	ID(ctx context.Context) (graphql.ID, error)
+	Name(ctx context.Context) (string, error)
 }
 
-type FileInput struct {
-  Name string
+// NodeResolver resolves the Node interface with the resolver of the concrete type held by Result
+type NodeResolver struct {
+	Result nodeResolver
 }
 
-type Folder struct {
-  ID    string
-  Name  string
-  Files []*File
+func (r NodeResolver) ID(ctx context.Context) (graphql.ID, error) {
+	return r.Result.ID(ctx)
 }
 
-type FolderResolver struct {
-  R *Folder
+func (r NodeResolver) Name(ctx context.Context) (string, error) {
+	return r.Result.Name(ctx)
 }
 
-func (r FolderResolver) ID() graphql.ID {
-  id := graphql.ID(r.R.ID)
-  return id
+func (r NodeResolver) ToPerson() (*PersonResolver, bool) {
+	res, ok := r.Result.(*PersonResolver)
+	return res, ok
 }
 
-func (r FolderResolver) Name() string {
-  return r.R.Name
+func (r NodeResolver) ToFolder() (*FolderResolver, bool) {
+	res, ok := r.Result.(*FolderResolver)
+	return res, ok
 }
 
-func (r FolderResolver) Files() []*FileResolver {
-  items := []*FileResolver{}
-  for _, itm := range r.R.Files {
-    items = append(items, &FileResolver{itm})
-  }
-  return items
+func (r NodeResolver) ToFile() (*FileResolver, bool) {
+	res, ok := r.Result.(*FileResolver)
+	return res, ok
 }
 
-type FolderInput struct {
-  ID   *string
-  Name string
+// NewNodeResolver wraps the model of a type implementing Node into the resolver of its type
+func NewNodeResolver(model Node) *NodeResolver {
+	switch m := model.(type) {
+	case *Person:
+		return &NodeResolver{&PersonResolver{m}}
+	case *Folder:
+		return &NodeResolver{&FolderResolver{m}}
+	case *File:
+		return &NodeResolver{&FileResolver{m}}
+	}
+	return nil
 }
 
 type Person struct {
-  Email   string
-  Friends []*Person
-  ID      string
-  Name    string
+	ID      string
+	Name    string
+	Email   string
+	Friends []*Person
 }
 
 type PersonResolver struct {
-  R *Person
+	R *Person
 }
 
-func (r PersonResolver) ID() graphql.ID {
-  id := graphql.ID(r.R.ID)
-  return id
+// PersonFieldResolvers are the resolver functions of the fields with arguments, which have to be implemented manually
+type PersonFieldResolvers interface {
+	Friends(ctx context.Context, args PersonFriendsArgs) ([]*PersonResolver, error)
 }
 
-func (r PersonResolver) Name() string {
-  return r.R.Name
+var _ PersonFieldResolvers = PersonResolver{}
+
+func (r PersonResolver) ID(ctx context.Context) (graphql.ID, error) {
+	id := graphql.ID(r.R.ID)
+	return id, nil
 }
 
-func (r PersonResolver) Email() string {
-  return r.R.Email
+func (r PersonResolver) Name(ctx context.Context) (string, error) {
+	return r.R.Name, nil
 }
 
-type PersonInput struct {
-  Name  string
-  Email string
+func (r PersonResolver) Email(ctx context.Context) (string, error) {
+	return r.R.Email, nil
+}
+
+type Folder struct {
+	ID    string
+	Name  string
+	Files []*File
+}
+
+type FolderResolver struct {
+	R *Folder
+}
+
+func (r FolderResolver) ID(ctx context.Context) (graphql.ID, error) {
+	id := graphql.ID(r.R.ID)
+	return id, nil
+}
+
+func (r FolderResolver) Name(ctx context.Context) (string, error) {
+	return r.R.Name, nil
+}
+
+func (r FolderResolver) Files(ctx context.Context) ([]*FileResolver, error) {
+	items := []*FileResolver{}
+	for _, itm := range r.R.Files {
+		if itm == nil {
+			items = append(items, nil)
+			continue
+		}
+		items = append(items, &FileResolver{itm})
+	}
+	return items, nil
+}
+
+type File struct {
+	ID     string
+	Name   string
+	Folder Folder
+}
+
+type FileResolver struct {
+	R *File
+}
+
+func (r FileResolver) ID(ctx context.Context) (graphql.ID, error) {
+	id := graphql.ID(r.R.ID)
+	return id, nil
+}
+
+func (r FileResolver) Name(ctx context.Context) (string, error) {
+	return r.R.Name, nil
+}
+
+func (r FileResolver) Folder(ctx context.Context) (FolderResolver, error) {
+	return FolderResolver{&r.R.Folder}, nil
 }
 
 type SearchResultResolver struct {
-  Result interface{}
+	Result interface{}
 }
 
 func (r *SearchResultResolver) ToFolder() (*FolderResolver, bool) {
-  res, ok := r.Result.(*FolderResolver)
-  return res, ok
+	res, ok := r.Result.(*FolderResolver)
+	return res, ok
 }
 
 func (r *SearchResultResolver) ToFile() (*FileResolver, bool) {
-  res, ok := r.Result.(*FileResolver)
-  return res, ok
+	res, ok := r.Result.(*FileResolver)
+	return res, ok
+}
+
+type QueryPersonArgs struct {
+	ID string
 }
 
-type CreateFileRequest struct {
-  FolderId string
-  File     FileInput
+type QuerySearchArgs struct {
+	Text string
 }
 
-type CreatePersonRequest struct {
-  Person PersonInput
+type QueryNodesArgs struct {
+	Name string
 }
 
-type CreateFolderRequest struct {
-  Folder FolderInput
+type MutationCreatePersonArgs struct {
+	Person PersonInput
 }
 
-type FriendsRequest struct {
-  First *int32
-  After *string
+type MutationCreateFolderArgs struct {
+	Folder FolderInput
 }
 
-type SearchRequest struct {
-  Text string
+type MutationCreateFileArgs struct {
+	FolderId string
+	File     FileInput
 }
 
-type PersonRequest struct {
-  ID string
+type PersonFriendsArgs struct {
+	First int32
+	After *string
 }
 
+type QueryResolver interface {
+	Person(ctx context.Context, args QueryPersonArgs) (PersonResolver, error)
+	Search(ctx context.Context, args QuerySearchArgs) ([]*SearchResultResolver, error)
+	Nodes(ctx context.Context, args QueryNodesArgs) ([]*NodeResolver, error)
+}
+
+type MutationResolver interface {
+	CreatePerson(ctx context.Context, args MutationCreatePersonArgs) (PersonResolver, error)
+	CreateFolder(ctx context.Context, args MutationCreateFolderArgs) (FolderResolver, error)
+	CreateFile(ctx context.Context, args MutationCreateFileArgs) (FileResolver, error)
+}
+
+type SubscriptionResolver interface {
+	PersonCreated(ctx context.Context) (<-chan PersonResolver, error)
+}
+
+// GqlResolver resolves the fields of the operation types of the schema
 type GqlResolver interface {
-  CreatePerson(CreatePersonRequest) PersonResolver
-  CreateFolder(CreateFolderRequest) FolderResolver
-  CreateFile(CreateFileRequest) FileResolver
-  Person(PersonRequest) PersonResolver
-  Search(SearchRequest) []*SearchResultResolver
+	QueryResolver
+	MutationResolver
+	SubscriptionResolver
+}
+
+// LoaderConfig configures the batching of the loaders
+type LoaderConfig struct {
+	// Wait is how long a loader collects ids before fetching them
+	Wait time.Duration
+	// MaxBatch is the maximum number of ids fetched at once, a batch is fetched as soon as it is full. 0 means no limit
+	MaxBatch int
+}
+
+// DefaultLoaderConfig is the LoaderConfig of NewGqlServer
+var DefaultLoaderConfig = LoaderConfig{
+	Wait:     2 * time.Millisecond,
+	MaxBatch: 100,
+}
+
+// Fetchers are the batch functions of the loaders. A function returns the models of the ids in the same order,
+// with nil for the ids which do not exist, or an error failing the loads of the whole batch.
+// Its context has the earliest deadline of the loads of the batch
+type Fetchers struct {
+	Person func(ctx context.Context, ids []string) ([]*Person, error)
+	Folder func(ctx context.Context, ids []string) ([]*Folder, error)
+	File   func(ctx context.Context, ids []string) ([]*File, error)
+}
+
+// Loaders are the batch loaders of a request, the models they load are cached for the request but the errors are not
+type Loaders struct {
+	Person *PersonLoader
+	Folder *FolderLoader
+	File   *FileLoader
+}
+
+// NewLoaders creates the loaders of a request, their functions are called with ctx
+func NewLoaders(ctx context.Context, fetchers Fetchers, config LoaderConfig) *Loaders {
+	return &Loaders{
+		Person: &PersonLoader{newLoader(ctx, config, "Person", func(ctx context.Context, ids []string) ([]interface{}, error) {
+			if fetchers.Person == nil {
+				return nil, errors.New("no Fetchers.Person function to load Person")
+			}
+			models, err := fetchers.Person(ctx, ids)
+			values := make([]interface{}, len(models))
+			for i, m := range models {
+				values[i] = m
+			}
+			return values, err
+		})},
+		Folder: &FolderLoader{newLoader(ctx, config, "Folder", func(ctx context.Context, ids []string) ([]interface{}, error) {
+			if fetchers.Folder == nil {
+				return nil, errors.New("no Fetchers.Folder function to load Folder")
+			}
+			models, err := fetchers.Folder(ctx, ids)
+			values := make([]interface{}, len(models))
+			for i, m := range models {
+				values[i] = m
+			}
+			return values, err
+		})},
+		File: &FileLoader{newLoader(ctx, config, "File", func(ctx context.Context, ids []string) ([]interface{}, error) {
+			if fetchers.File == nil {
+				return nil, errors.New("no Fetchers.File function to load File")
+			}
+			models, err := fetchers.File(ctx, ids)
+			values := make([]interface{}, len(models))
+			for i, m := range models {
+				values[i] = m
+			}
+			return values, err
+		})},
+	}
+}
+
+type loadersKey struct{}
+
+// WithLoaders returns a copy of the context holding the loaders
+func WithLoaders(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, loaders)
+}
+
+// LoadersFrom returns the loaders of the request of the context, or nil outside of a request of GqlServer
+func LoadersFrom(ctx context.Context) *Loaders {
+	switch v := ctx.Value(loadersKey{}).(type) {
+	case *Loaders:
+		return v
+	case *eventLoaders:
+		return v.get(ctx)
+	}
+	return nil
+}
+
+// withEventLoaders returns a copy of the context of a subscription which gets new loaders for each of its events,
+// so the models are not cached for the whole subscription
+func withEventLoaders(ctx context.Context, fetchers Fetchers, config LoaderConfig) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &eventLoaders{
+		ctx:      ctx,
+		fetchers: fetchers,
+		config:   config,
+		loaders:  map[<-chan struct{}]*Loaders{},
+	})
+}
+
+// eventLoaders creates the loaders of the events of a subscription. graphql-go resolves each event with a context
+// of its own which is done once the event is resolved, so the loaders are kept by the done channel of the context
+// they are requested with until it is closed
+type eventLoaders struct {
+	ctx      context.Context
+	fetchers Fetchers
+	config   LoaderConfig
+
+	mu      sync.Mutex
+	loaders map[<-chan struct{}]*Loaders
+}
+
+func (e *eventLoaders) get(ctx context.Context) *Loaders {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// the loaders of the events already resolved are dropped
+	for done := range e.loaders {
+		select {
+		case <-done:
+			delete(e.loaders, done)
+		default:
+		}
+	}
+
+	loaders, ok := e.loaders[ctx.Done()]
+	if !ok {
+		loaders = NewLoaders(e.ctx, e.fetchers, e.config)
+		e.loaders[ctx.Done()] = loaders
+	}
+	return loaders
+}
+
+// PersonLoader loads the Person models of a request by their ID in batches
+type PersonLoader struct {
+	l *loader
+}
+
+// Load returns the Person of the id, once the batch it is part of is fetched or ctx is done
+func (l *PersonLoader) Load(ctx context.Context, id string) (*Person, error) {
+	v, err := l.l.load(ctx, id)
+	m, _ := v.(*Person)
+	return m, err
+}
+
+// LoadAll returns the Person models of the ids, which are fetched in the same batch
+func (l *PersonLoader) LoadAll(ctx context.Context, ids []string) ([]*Person, error) {
+	values, err := l.l.loadAll(ctx, ids)
+	models := make([]*Person, len(values))
+	for i, v := range values {
+		models[i], _ = v.(*Person)
+	}
+	return models, err
+}
+
+// FolderLoader loads the Folder models of a request by their ID in batches
+type FolderLoader struct {
+	l *loader
+}
+
+// Load returns the Folder of the id, once the batch it is part of is fetched or ctx is done
+func (l *FolderLoader) Load(ctx context.Context, id string) (*Folder, error) {
+	v, err := l.l.load(ctx, id)
+	m, _ := v.(*Folder)
+	return m, err
+}
+
+// LoadAll returns the Folder models of the ids, which are fetched in the same batch
+func (l *FolderLoader) LoadAll(ctx context.Context, ids []string) ([]*Folder, error) {
+	values, err := l.l.loadAll(ctx, ids)
+	models := make([]*Folder, len(values))
+	for i, v := range values {
+		models[i], _ = v.(*Folder)
+	}
+	return models, err
+}
+
+// FileLoader loads the File models of a request by their ID in batches
+type FileLoader struct {
+	l *loader
+}
+
+// Load returns the File of the id, once the batch it is part of is fetched or ctx is done
+func (l *FileLoader) Load(ctx context.Context, id string) (*File, error) {
+	v, err := l.l.load(ctx, id)
+	m, _ := v.(*File)
+	return m, err
+}
+
+// LoadAll returns the File models of the ids, which are fetched in the same batch
+func (l *FileLoader) LoadAll(ctx context.Context, ids []string) ([]*File, error) {
+	values, err := l.l.loadAll(ctx, ids)
+	models := make([]*File, len(values))
+	for i, v := range values {
+		models[i], _ = v.(*File)
+	}
+	return models, err
+}
+
+// loader batches and caches the loads of a type, the typed loaders convert its values
+type loader struct {
+	ctx    context.Context
+	config LoaderConfig
+	name   string
+	fetch  func(ctx context.Context, ids []string) ([]interface{}, error)
+
+	mu      sync.Mutex
+	results map[string]*loaderResult
+	// batch collects the ids of the next fetch
+	batch *loaderBatch
+}
+
+type loaderBatch struct {
+	ids     []string
+	results []*loaderResult
+	// deadline is the earliest deadline of the loads, zero when none has one
+	deadline time.Time
+}
+
+type loaderResult struct {
+	// done is closed once the value is fetched
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func newLoader(ctx context.Context, config LoaderConfig, name string, fetch func(ctx context.Context, ids []string) ([]interface{}, error)) *loader {
+	return &loader{
+		ctx:     ctx,
+		config:  config,
+		name:    name,
+		fetch:   fetch,
+		results: map[string]*loaderResult{},
+	}
+}
+
+func (l *loader) load(ctx context.Context, id string) (interface{}, error) {
+	r := l.enqueue(ctx, id)
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *loader) loadAll(ctx context.Context, ids []string) ([]interface{}, error) {
+	results := make([]*loaderResult, len(ids))
+	for i, id := range ids {
+		results[i] = l.enqueue(ctx, id)
+	}
+	values := make([]interface{}, len(ids))
+	var err error
+	for i, r := range results {
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		values[i] = r.value
+		if err == nil {
+			err = r.err
+		}
+	}
+	return values, err
+}
+
+// enqueue adds the id to the current batch unless it is already loaded or being loaded
+func (l *loader) enqueue(ctx context.Context, id string) *loaderResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r, ok := l.results[id]; ok {
+		return r
+	}
+	r := &loaderResult{done: make(chan struct{})}
+	l.results[id] = r
+
+	if l.batch == nil {
+		l.batch = &loaderBatch{}
+		go l.wait(l.batch)
+	}
+	b := l.batch
+	b.ids = append(b.ids, id)
+	b.results = append(b.results, r)
+	if d, ok := ctx.Deadline(); ok && (b.deadline.IsZero() || d.Before(b.deadline)) {
+		b.deadline = d
+	}
+
+	if l.config.MaxBatch > 0 && len(b.ids) >= l.config.MaxBatch {
+		l.batch = nil
+		go l.run(b)
+	}
+	return r
+}
+
+// wait fetches the batch once the wait is over, unless it was fetched when it got full
+func (l *loader) wait(b *loaderBatch) {
+	time.Sleep(l.config.Wait)
+	l.mu.Lock()
+	if l.batch != b {
+		l.mu.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mu.Unlock()
+	l.run(b)
+}
+
+func (l *loader) run(b *loaderBatch) {
+	ctx := l.ctx
+	if !b.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, b.deadline)
+		defer cancel()
+	}
+
+	values, err := l.fetch(ctx, b.ids)
+	if err == nil && len(values) != len(b.ids) {
+		err = fmt.Errorf("%s loader fetched %d models for %d ids", l.name, len(values), len(b.ids))
+	}
+	if err != nil {
+		// the ids of a failed batch are fetched again by the next loads
+		l.mu.Lock()
+		for i, id := range b.ids {
+			if l.results[id] == b.results[i] {
+				delete(l.results, id)
+			}
+		}
+		l.mu.Unlock()
+	}
+	for i, r := range b.results {
+		if err != nil {
+			r.err = err
+		} else {
+			r.value = values[i]
+		}
+		close(r.done)
+	}
+}
+
+// maxOperationCost bounds the costs so that they do not overflow
+const maxOperationCost = math.MaxInt32
+
+// costRoots are the types of the operations
+var costRoots = map[string]string{
+	"mutation":     "Mutation",
+	"query":        "Query",
+	"subscription": "Subscription",
+}
+
+type costField struct {
+	// Type is the type of the selections of the field, empty for scalars and enums
+	Type        string
+	Weight      int
+	Multipliers []costMultiplier
+}
+
+// costMultiplier is an argument multiplying the cost of a field, its default value applies when it is not given
+// or is not positive
+type costMultiplier struct {
+	Arg     string
+	Default int
+}
+
+// costFields are the fields counting in the cost of an operation by type and name
+var costFields = map[string]map[string]costField{
+	"Query": {
+		"person": {Type: "Person", Weight: 1},
+		"search": {Type: "SearchResult", Weight: 1},
+		"nodes":  {Type: "Node", Weight: 1},
+	},
+	"Mutation": {
+		"createPerson": {Type: "Person", Weight: 1},
+		"createFolder": {Type: "Folder", Weight: 1},
+		"createFile":   {Type: "File", Weight: 1},
+	},
+	"Subscription": {
+		"personCreated": {Type: "Person", Weight: 1},
+	},
+	"Person": {
+		"friends": {Type: "Person", Weight: 1, Multipliers: []costMultiplier{{Arg: "first", Default: 10}}},
+	},
+	"Folder": {
+		"files": {Type: "File", Weight: 1},
+	},
+	"File": {
+		"folder": {Type: "Folder", Weight: 1},
+	},
+}
+
+// OperationCost returns the static cost of an operation of the query. The cost of a field is its weight plus the cost
+// of its selections, multiplied by the values of its multiplier arguments, a list counting for its length.
+// The fields of scalars and enums are free and the other ones weigh 1, unless set otherwise with @cost.
+// As the fragments on the possible types of an abstract field all count, the cost is an upper bound
+func OperationCost(query, operationName string, variables map[string]interface{}) (int, error) {
+	doc, err := gqlquery.Parse(query)
+	if err != nil {
+		return 0, err
+	}
+
+	op := doc.Operation(operationName)
+	if op == nil {
+		return 0, fmt.Errorf("unknown operation %q", operationName)
+	}
+
+	c := &costCounter{
+		doc:       doc,
+		op:        op,
+		variables: variables,
+		spreading: map[string]bool{},
+	}
+	return c.selections(costRoots[op.Kind], op.Selections), nil
+}
+
+type costCounter struct {
+	doc       *gqlquery.Document
+	op        *gqlquery.Operation
+	variables map[string]interface{}
+	// spreading holds the fragments being counted, to stop at invalid cycles which graphql-go reports
+	spreading map[string]bool
+}
+
+func (c *costCounter) selections(parent string, sels []gqlquery.Selection) int {
+	cost := 0
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *gqlquery.Field:
+			cost = costAdd(cost, c.field(parent, sel))
+		case *gqlquery.FragmentSpread:
+			f, ok := c.doc.Fragments[sel.Name]
+			if !ok || c.spreading[sel.Name] {
+				continue
+			}
+			c.spreading[sel.Name] = true
+			cost = costAdd(cost, c.selections(f.On, f.Selections))
+			delete(c.spreading, sel.Name)
+		case *gqlquery.InlineFragment:
+			on := parent
+			if sel.On != "" {
+				on = sel.On
+			}
+			cost = costAdd(cost, c.selections(on, sel.Selections))
+		}
+	}
+	return cost
+}
+
+func (c *costCounter) field(parent string, sel *gqlquery.Field) int {
+	f, ok := costFields[parent][sel.Name]
+	if !ok {
+		return 0
+	}
+	cost := f.Weight
+	if f.Type != "" {
+		cost = costAdd(cost, c.selections(f.Type, sel.Selections))
+	}
+	for _, m := range f.Multipliers {
+		cost = costMul(cost, c.multiplier(sel.Arguments, m))
+	}
+	return cost
+}
+
+// multiplier returns the value of a multiplier argument, bounded by maxOperationCost, or its default value
+// when it is omitted or not positive, so that a field always counts
+func (c *costCounter) multiplier(args map[string]interface{}, m costMultiplier) int {
+	v, ok := args[m.Arg]
+	if name, isVar := v.(gqlquery.Variable); isVar {
+		v, ok = c.variables[string(name)]
+		if def := c.op.Var(string(name)); !ok && def != nil {
+			v, ok = def.Default, def.Default != nil
+		}
+	}
+	if !ok {
+		return m.Default
+	}
+
+	n := 0
+	switch v := v.(type) {
+	case int:
+		n = v
+	case float64:
+		if v >= 1 {
+			n = int(math.Min(v, maxOperationCost))
+		}
+	case []interface{}:
+		n = len(v)
+	}
+	if n < 1 {
+		return m.Default
+	}
+	return n
+}
+
+func costAdd(a, b int) int {
+	if a > maxOperationCost-b {
+		return maxOperationCost
+	}
+	return a + b
+}
+
+// costMul multiplies a cost by a positive multiplier
+func costMul(a, n int) int {
+	if a > maxOperationCost/n {
+		return maxOperationCost
+	}
+	return a * n
 }
 
 var Schema = `
 
+directive @cost(weight: Int = 1, multipliers: [String!]) on FIELD_DEFINITION
+
 schema {
   query: Query
   mutation: Mutation
+  subscription: Subscription
 }
 
 type Query {
   person(id: ID!): Person!
   search(text: String!): [SearchResult]!
+  nodes(name: String!): [Node]!
 }
 
 type Mutation {
@@ -158,6 +765,10 @@ type Mutation {
   createFile(folderId: ID!, file: FileInput!): File!
 }
 
+type Subscription {
+  personCreated: Person!
+}
+
 input PersonInput {
   name: String!
   email: String!
@@ -172,20 +783,25 @@ input FileInput {
   name: String!
 }
 
-type Person {
+interface Node {
+  id: ID!
+  name: String!
+}
+
+type Person implements Node {
   id: ID!
   name: String!
   email: String!
-  friends(first: Int, after: ID): [Person]!
+  friends(first: Int = 10, after: ID): [Person]! @cost(multipliers: ["first"])
 }
 
-type Folder {
+type Folder implements Node {
   id: ID!
   name: String!
   files: [File]!
 }
 
-type File {
+type File implements Node {
   id: ID!
   name: String!
   folder: Folder!
@@ -0,0 +1,164 @@
+package api
+
+import (
+  "context"
+  "crypto/sha256"
+  "encoding/hex"
+  "encoding/json"
+  "net/http"
+  "net/url"
+  "strings"
+  "testing"
+)
+
+const apqQuery = `{ person(id: "1") { name } }`
+
+// apqRequest is the body of a request of the query with its hash, the query is left out when it is empty
+func apqRequest(query, hash string, version int) string {
+  body := map[string]interface{}{
+    "extensions": map[string]interface{}{
+      "persistedQuery": map[string]interface{}{"version": version, "sha256Hash": hash},
+    },
+  }
+  if query != "" {
+    body["query"] = query
+  }
+  data, _ := json.Marshal(body)
+  return string(data)
+}
+
+func sha256Hash(query string) string {
+  sum := sha256.Sum256([]byte(query))
+  return hex.EncodeToString(sum[:])
+}
+
+// expectCode checks that the response has the single error of the code
+func expectCode(t *testing.T, res testResponse, code string) {
+  t.Helper()
+  if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != code {
+    t.Errorf("received %+v, want the error %s", res, code)
+  }
+}
+
+// expectPerson checks that the response has the name of the person of apqQuery
+func expectPerson(t *testing.T, res testResponse) {
+  t.Helper()
+  if person, _ := res.Data["person"].(map[string]interface{}); len(res.Errors) > 0 || person["name"] != "Person 1" {
+    t.Errorf("received %+v, want the person", res)
+  }
+}
+
+func TestPersistedQuery(t *testing.T) {
+  _, srv := newTestServer(t, nil)
+  hash := sha256Hash(apqQuery)
+
+  // the hash alone is unknown until the client sends the query with it
+  var res testResponse
+  post(t, srv, apqRequest("", hash, 1), &res)
+  expectCode(t, res, "PERSISTED_QUERY_NOT_FOUND")
+  if res.Errors[0].Message != "PersistedQueryNotFound" {
+    t.Errorf("received the message %q, want PersistedQueryNotFound", res.Errors[0].Message)
+  }
+
+  res = testResponse{}
+  post(t, srv, apqRequest(apqQuery, hash, 1), &res)
+  expectPerson(t, res)
+
+  res = testResponse{}
+  post(t, srv, apqRequest("", hash, 1), &res)
+  expectPerson(t, res)
+
+  // GET requests can send the extensions only
+  ext := `{"persistedQuery": {"version": 1, "sha256Hash": "` + hash + `"}}`
+  get, err := http.Get(srv.URL + DefaultPath + "?extensions=" + url.QueryEscape(ext))
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer get.Body.Close()
+  res = testResponse{}
+  if err := json.NewDecoder(get.Body).Decode(&res); err != nil {
+    t.Fatal(err)
+  }
+  expectPerson(t, res)
+}
+
+func TestPersistedQueryErrors(t *testing.T) {
+  _, srv := newTestServer(t, nil)
+  hash := sha256Hash(apqQuery)
+
+  var res testResponse
+  post(t, srv, apqRequest(apqQuery, sha256Hash("{ other }"), 1), &res)
+  expectCode(t, res, "INVALID_PERSISTED_QUERY_HASH")
+
+  res = testResponse{}
+  post(t, srv, apqRequest(apqQuery, hash, 2), &res)
+  expectCode(t, res, "PERSISTED_QUERY_VERSION_NOT_SUPPORTED")
+
+  // neither request stored the query
+  res = testResponse{}
+  post(t, srv, apqRequest("", hash, 1), &res)
+  expectCode(t, res, "PERSISTED_QUERY_NOT_FOUND")
+
+  _, srv = newTestServer(t, func(g *GqlServer) {
+    g.PersistedQueries = nil
+  })
+  res = testResponse{}
+  post(t, srv, apqRequest("", hash, 1), &res)
+  expectCode(t, res, "PERSISTED_QUERY_NOT_SUPPORTED")
+}
+
+func TestPersistedQueryStoredOnceValid(t *testing.T) {
+  _, srv := newTestServer(t, func(g *GqlServer) {
+    g.MaxQuerySize = 64
+  })
+
+  // the invalid and the too large queries are executed but not stored
+  for _, query := range []string{
+    `{ person(id: "1") { age } }`,
+    `{ person(id: "1") { name } } # a comment making the query too large`,
+  } {
+    hash := sha256Hash(query)
+    var res testResponse
+    post(t, srv, apqRequest(query, hash, 1), &res)
+    if len(res.Errors) == 0 {
+      t.Errorf("received %+v for %s, want an error", res, query)
+    }
+    res = testResponse{}
+    post(t, srv, apqRequest("", hash, 1), &res)
+    expectCode(t, res, "PERSISTED_QUERY_NOT_FOUND")
+  }
+
+  // the valid query is stored, it is validated with its variables
+  query := `query($id: ID!) { person(id: $id) { name } }`
+  body := func(query string) string {
+    var req map[string]interface{}
+    json.Unmarshal([]byte(apqRequest(query, sha256Hash(query), 1)), &req)
+    req["variables"] = map[string]interface{}{"id": "1"}
+    data, _ := json.Marshal(req)
+    return string(data)
+  }
+  var res testResponse
+  post(t, srv, body(query), &res)
+  expectPerson(t, res)
+  res = testResponse{}
+  post(t, srv, strings.Replace(body(query), `"query":`, `"ignored":`, 1), &res)
+  expectPerson(t, res)
+}
+
+func TestLRUPersistedQueryStore(t *testing.T) {
+  ctx := context.Background()
+  store := NewLRUPersistedQueryStore(2)
+  store.Add(ctx, "a", "{ a }")
+  store.Add(ctx, "b", "{ b }")
+  // reading a makes b the least recently used query
+  if q, ok := store.Get(ctx, "a"); !ok || q != "{ a }" {
+    t.Errorf("got %q, %v for a", q, ok)
+  }
+  store.Add(ctx, "c", "{ c }")
+
+  for hash, want := range map[string]bool{"a": true, "b": false, "c": true} {
+    if _, ok := store.Get(ctx, hash); ok != want {
+      t.Errorf("got %v for %s, want %v", ok, hash, want)
+    }
+  }
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+  "context"
+  "fmt"
+  "net/http"
+  "net/http/httptest"
+  "strings"
+  "sync"
+  "testing"
+  "time"
+)
+
+// batchResolver records the concurrency of the person queries and the order of the people created
+type batchResolver struct {
+  *testResolver
+  delay time.Duration
+
+  mu      sync.Mutex
+  running int
+  max     int
+  created []string
+}
+
+func (r *batchResolver) Person(ctx context.Context, args QueryPersonArgs) (PersonResolver, error) {
+  r.mu.Lock()
+  r.running++
+  if r.running > r.max {
+    r.max = r.running
+  }
+  r.mu.Unlock()
+  defer func() {
+    r.mu.Lock()
+    r.running--
+    r.mu.Unlock()
+  }()
+
+  select {
+  case <-time.After(r.delay):
+  case <-ctx.Done():
+    return PersonResolver{}, ctx.Err()
+  }
+  return r.testResolver.Person(ctx, args)
+}
+
+// CreatePerson takes longer for the shorter names, so that the mutations run in parallel finish out of order
+func (r *batchResolver) CreatePerson(ctx context.Context, args MutationCreatePersonArgs) (PersonResolver, error) {
+  time.Sleep(time.Duration(10-len(args.Person.Name)) * 10 * time.Millisecond)
+  r.mu.Lock()
+  r.created = append(r.created, args.Person.Name)
+  r.mu.Unlock()
+  return PersonResolver{R: &Person{ID: args.Person.Name, Name: args.Person.Name}}, nil
+}
+
+func newBatchServer(t *testing.T, delay time.Duration, configure func(g *GqlServer)) (*batchResolver, *httptest.Server) {
+  r := &batchResolver{testResolver: newTestResolver(), delay: delay}
+  g := NewGqlServer(r, "0", nil)
+  configure(g)
+  return r, serve(t, g)
+}
+
+func personQuery(id int) string {
+  return fmt.Sprintf(`{"query": "{ person(id: \"%d\") { name } }"}`, id)
+}
+
+func createPersonMutation(name string) string {
+  return fmt.Sprintf(`{"query": "mutation { createPerson(person: {name: \"%s\", email: \"\"}) { name } }"}`, name)
+}
+
+func batch(ops ...string) string {
+  return "[" + strings.Join(ops, ",") + "]"
+}
+
+func TestBatchBody(t *testing.T) {
+  _, srv := newTestServer(t, nil)
+
+  for _, tc := range []struct {
+    body   string
+    status int
+    batch  bool
+  }{
+    {body: personQuery(1), status: http.StatusOK},
+    {body: " \n\t" + personQuery(1), status: http.StatusOK},
+    {body: batch(personQuery(1)), status: http.StatusOK, batch: true},
+    {body: "\r\n  " + batch(personQuery(1), personQuery(2)), status: http.StatusOK, batch: true},
+    {body: "[]", status: http.StatusBadRequest},
+    {body: " [ ] ", status: http.StatusBadRequest},
+    {body: "  ", status: http.StatusBadRequest},
+    {body: `"{ person(id: \"1\") { name } }"`, status: http.StatusBadRequest},
+    {body: `[{"query": 1}]`, status: http.StatusBadRequest},
+  } {
+    if tc.status != http.StatusOK {
+      if status := post(t, srv, tc.body, nil); status != tc.status {
+        t.Errorf("%q: status %d, want %d", tc.body, status, tc.status)
+      }
+      continue
+    }
+
+    var responses []testResponse
+    if tc.batch {
+      post(t, srv, tc.body, &responses)
+    } else {
+      var res testResponse
+      post(t, srv, tc.body, &res)
+      responses = append(responses, res)
+    }
+    for i, res := range responses {
+      person, _ := res.Data["person"].(map[string]interface{})
+      if want := fmt.Sprintf("Person %d", i+1); person["name"] != want || len(res.Errors) > 0 {
+        t.Errorf("%q: response %d is %+v, want %s", tc.body, i, res, want)
+      }
+    }
+  }
+}
+
+func TestBatchMaxSize(t *testing.T) {
+  _, srv := newTestServer(t, func(g *GqlServer) {
+    g.MaxBatchSize = 2
+  })
+
+  if status := post(t, srv, batch(personQuery(1), personQuery(2), personQuery(3)), nil); status != http.StatusBadRequest {
+    t.Errorf("a batch over the limit got the status %d", status)
+  }
+  var responses []testResponse
+  if status := post(t, srv, batch(personQuery(1), personQuery(2)), &responses); status != http.StatusOK || len(responses) != 2 {
+    t.Errorf("a batch within the limit got the status %d and %d responses", status, len(responses))
+  }
+}
+
+func TestBatchConcurrencyAndOrder(t *testing.T) {
+  r, srv := newBatchServer(t, 20*time.Millisecond, func(g *GqlServer) {
+    g.BatchConcurrency = 2
+  })
+
+  var ops []string
+  for i := 1; i <= 6; i++ {
+    ops = append(ops, personQuery(i))
+  }
+  var responses []testResponse
+  post(t, srv, batch(ops...), &responses)
+
+  if len(responses) != len(ops) {
+    t.Fatalf("%d responses for %d operations", len(responses), len(ops))
+  }
+  for i, res := range responses {
+    person, _ := res.Data["person"].(map[string]interface{})
+    if want := fmt.Sprintf("Person %d", i+1); person["name"] != want {
+      t.Errorf("response %d is %+v, want %s", i, res, want)
+    }
+  }
+  r.mu.Lock()
+  defer r.mu.Unlock()
+  if r.max != 2 {
+    t.Errorf("%d operations ran at once, want 2", r.max)
+  }
+}
+
+func TestBatchSequentialMutations(t *testing.T) {
+  names := []string{"a", "bb", "ccc", "dddd"}
+  var ops []string
+  for i, name := range names {
+    ops = append(ops, createPersonMutation(name), personQuery(i))
+  }
+
+  for _, sequential := range []bool{true, false} {
+    r, srv := newBatchServer(t, 0, func(g *GqlServer) {
+      g.SequentialMutations = sequential
+    })
+    var responses []testResponse
+    post(t, srv, batch(ops...), &responses)
+
+    for i, name := range names {
+      created, _ := responses[2*i].Data["createPerson"].(map[string]interface{})
+      if created["name"] != name {
+        t.Errorf("response %d is %+v, want %s", 2*i, responses[2*i], name)
+      }
+    }
+    r.mu.Lock()
+    inOrder := strings.Join(r.created, ",") == strings.Join(names, ",")
+    r.mu.Unlock()
+    if inOrder != sequential {
+      t.Errorf("sequential %v: the mutations ran in the order %v", sequential, r.created)
+    }
+  }
+}
+
+func TestBatchOperationTimeout(t *testing.T) {
+  _, srv := newBatchServer(t, time.Hour, func(g *GqlServer) {
+    g.OperationTimeout = 20 * time.Millisecond
+  })
+
+  start := time.Now()
+  var responses []testResponse
+  post(t, srv, batch(personQuery(1), personQuery(2)), &responses)
+  if elapsed := time.Since(start); elapsed > time.Second {
+    t.Errorf("the batch took %v", elapsed)
+  }
+  for i, res := range responses {
+    if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Message, context.DeadlineExceeded.Error()) {
+      t.Errorf("response %d is %+v, want the deadline to be exceeded", i, res)
+    }
+  }
+}
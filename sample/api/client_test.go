@@ -0,0 +1,117 @@
+package api
+
+import (
+  "context"
+  "strings"
+  "testing"
+
+  "github.com/dealtap/graphql-gen-go/sample/client"
+  graphql "github.com/graph-gophers/graphql-go"
+)
+
+// clientResolver resolves the people with three friends for the generated client to page through
+type clientResolver struct {
+  *testResolver
+}
+
+func (r *clientResolver) Person(ctx context.Context, args QueryPersonArgs) (PersonResolver, error) {
+  p := &Person{ID: args.ID, Name: "Person " + args.ID}
+  for _, id := range []string{"a", "b", "c"} {
+    p.Friends = append(p.Friends, &Person{ID: id, Name: "Friend " + id})
+  }
+  return PersonResolver{R: p}, nil
+}
+
+// newClient creates a client of a server configured by the function
+func newClient(t *testing.T, configure func(g *GqlServer)) (*testResolver, *client.Client) {
+  r := &clientResolver{newTestResolver()}
+  g := NewGqlServer(r, "0", nil)
+  if configure != nil {
+    configure(g)
+  }
+  return r.testResolver, client.NewClient(serve(t, g).URL + DefaultPath)
+}
+
+func TestClientQuery(t *testing.T) {
+  var info RequestInfo
+  _, c := newClient(t, func(g *GqlServer) {
+    g.Interceptors = append(g.Interceptors, func(ctx context.Context, req *GqlRequest, next OperationHandler) *graphql.Response {
+      info = *RequestInfoFrom(ctx)
+      // graphql-go adds the default values of the omitted variables to the map on execution
+      info.Variables = map[string]interface{}{}
+      for name, v := range req.Variables {
+        info.Variables[name] = v
+      }
+      return next(ctx, req)
+    })
+  })
+  c.Header.Set("Authorization", "Bearer token")
+
+  first := int32(2)
+  res, err := c.GetPerson(context.Background(), client.GetPersonVariables{ID: "1", First: &first})
+  if err != nil {
+    t.Fatal(err)
+  }
+  if res.Person.ID != "1" || res.Person.Name != "Person 1" {
+    t.Errorf("got the person %+v", res.Person)
+  }
+  var friends []string
+  for _, f := range res.Person.Friends {
+    friends = append(friends, f.ID+" "+f.Name)
+  }
+  if got, want := strings.Join(friends, ", "), "a Friend a, b Friend b"; got != want {
+    t.Errorf("got the friends %s, want %s", got, want)
+  }
+
+  if info.OperationName != "getPerson" || info.Header.Get("Authorization") != "Bearer token" {
+    t.Errorf("the server got the operation %q with the authorization %q", info.OperationName, info.Header.Get("Authorization"))
+  }
+  // the nil variables are left out of the request
+  if _, ok := info.Variables["first"]; !ok {
+    t.Errorf("the server got the variables %v, want first", info.Variables)
+  }
+  if _, err := c.GetPerson(context.Background(), client.GetPersonVariables{ID: "1"}); err != nil {
+    t.Fatal(err)
+  }
+  if _, ok := info.Variables["first"]; ok {
+    t.Errorf("the server got the variables %v, want first to be left out", info.Variables)
+  }
+}
+
+func TestClientMutation(t *testing.T) {
+  r, c := newClient(t, nil)
+  events := r.pubsub.Subscribe(context.Background(), "personCreated")
+
+  res, err := c.CreatePerson(context.Background(), client.CreatePersonVariables{
+    Person: client.PersonInput{Name: "Rey", Email: "rey@resistance"},
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  if res.Created.Name != "Rey" || res.Created.Email != "rey@resistance" {
+    t.Errorf("got the created person %+v", res.Created)
+  }
+  if p := (<-events).(*Person); p.Name != "Rey" {
+    t.Errorf("published %+v, want Rey", p)
+  }
+}
+
+func TestClientErrors(t *testing.T) {
+  _, c := newClient(t, func(g *GqlServer) {
+    g.Interceptors = append(g.Interceptors, rejectMutations)
+  })
+
+  _, err := c.CreatePerson(context.Background(), client.CreatePersonVariables{
+    Person: client.PersonInput{Name: "Rey", Email: "rey@resistance"},
+  })
+  errs, ok := err.(client.ClientErrors)
+  if !ok || len(errs) != 1 || errs[0].Message != "mutations are disabled" {
+    t.Errorf("got the error %#v, want the mutation to be rejected", err)
+  }
+
+  c.Endpoint = strings.TrimSuffix(c.Endpoint, DefaultPath) + "/missing"
+  if _, err := c.GetPerson(context.Background(), client.GetPersonVariables{ID: "1"}); err == nil ||
+    !strings.HasPrefix(err.Error(), "graphql: 404 Not Found") {
+    t.Errorf("got the error %v, want the status of the response", err)
+  }
+}
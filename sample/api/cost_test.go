@@ -0,0 +1,205 @@
+package api
+
+import (
+  "encoding/json"
+  "math"
+  "reflect"
+  "strings"
+  "testing"
+
+  graphql "github.com/graph-gophers/graphql-go"
+)
+
+func TestOperationCost(t *testing.T) {
+  for _, tc := range []struct {
+    name      string
+    query     string
+    operation string
+    variables map[string]interface{}
+    want      int
+  }{
+    {
+      name:  "scalar fields are free",
+      query: `{ person(id: "1") { id name } }`,
+      want:  1,
+    },
+    {
+      name:  "multipliers",
+      query: `{ person(id: "1") { friends(first: 3) { name friends(first: 2) { id } } } }`,
+      want:  1 + (1+2)*3,
+    },
+    {
+      name:  "multiplier default",
+      query: `{ person(id: "1") { friends { id } } }`,
+      want:  1 + 10,
+    },
+    {
+      name:  "non-positive multipliers count for the default",
+      query: `{ person(id: "1") { a: friends(first: 0) { id } b: friends(first: -5) { id } } }`,
+      want:  1 + 10 + 10,
+    },
+    {
+      name:      "non-positive variable",
+      query:     `query Friends($n: Int) { person(id: "1") { friends(first: $n) { id } } }`,
+      variables: map[string]interface{}{"n": float64(-3)},
+      want:      1 + 10,
+    },
+    {
+      name:      "large variable",
+      query:     `query Friends($n: Int) { person(id: "1") { friends(first: $n) { id } } }`,
+      variables: map[string]interface{}{"n": 1e300},
+      want:      math.MaxInt32,
+    },
+    {
+      name:  "variable default",
+      query: `query Friends($n: Int = 4) { person(id: "1") { friends(first: $n) { id } } }`,
+      want:  1 + 4,
+    },
+    {
+      name:      "variable",
+      query:     `query Friends($n: Int = 4) { person(id: "1") { friends(first: $n) { id } } }`,
+      variables: map[string]interface{}{"n": float64(5)},
+      want:      1 + 5,
+    },
+    {
+      name:      "named operation",
+      query:     `query A { person(id: "1") { id } } query B { a: person(id: "1") { id } b: person(id: "2") { id } }`,
+      operation: "B",
+      want:      2,
+    },
+    {
+      name:  "fragments",
+      query: `{ nodes(name: "x") { ... on Folder { files { folder { id } } } ...F } } fragment F on File { folder { id } }`,
+      want:  1 + 2 + 1,
+    },
+    {
+      name:  "fragment cycles stop",
+      query: `{ person(id: "1") { ...A } } fragment A on Person { friends { ...A } }`,
+      want:  1 + 10,
+    },
+  } {
+    cost, err := OperationCost(tc.query, tc.operation, tc.variables)
+    if err != nil || cost != tc.want {
+      t.Errorf("%s: cost %d, %v, want %d", tc.name, cost, err, tc.want)
+    }
+  }
+}
+
+func TestOperationCostErrors(t *testing.T) {
+  if _, err := OperationCost(`{ person(id: "1") { id }`, "", nil); err == nil {
+    t.Error("no error for a syntax error")
+  }
+  if _, err := OperationCost(`query A { person(id: "1") { id } }`, "B", nil); err == nil || err.Error() != `unknown operation "B"` {
+    t.Errorf("unknown operation returned %v", err)
+  }
+}
+
+func TestQueryLimits(t *testing.T) {
+  _, srv := newTestServer(t, func(g *GqlServer) {
+    g.MaxQuerySize = 100
+    g.MaxCost = 5
+  })
+
+  var res testResponse
+  post(t, srv, `{"query": "{ person(id: \"1\") { friends(first: 4) { name } } }"}`, &res)
+  if len(res.Errors) > 0 || res.Data["person"] == nil {
+    t.Errorf("received %+v, want the operation within the limits to be executed", res)
+  }
+
+  for _, tc := range []struct {
+    name    string
+    query   string
+    code    string
+    details map[string]interface{}
+  }{
+    {
+      name:    "size",
+      query:   `{ person(id: "1") { name } ` + strings.Repeat(" ", 100) + `}`,
+      code:    "QUERY_TOO_LARGE",
+      details: map[string]interface{}{"size": float64(128), "maxSize": float64(100)},
+    },
+    {
+      name:    "cost",
+      query:   `{ person(id: "1") { friends(first: 5) { name } } }`,
+      code:    "COST_LIMIT_EXCEEDED",
+      details: map[string]interface{}{"cost": float64(6), "maxCost": float64(5)},
+    },
+    {
+      name:    "negative multiplier",
+      query:   `{ person(id: "1") { friends(first: -5) { name } } }`,
+      code:    "COST_LIMIT_EXCEEDED",
+      details: map[string]interface{}{"cost": float64(11), "maxCost": float64(5)},
+    },
+    {
+      // graphql-go accepts the block comment which gqlquery does not parse
+      name:    "cost analysis",
+      query:   `{ person(id: "1") { name } } /* x */`,
+      code:    "COST_ANALYSIS_FAILED",
+      details: map[string]interface{}{},
+    },
+  } {
+    body, _ := json.Marshal(map[string]string{"query": tc.query})
+    var res testResponse
+    post(t, srv, string(body), &res)
+    if len(res.Errors) != 1 || res.Data != nil {
+      t.Errorf("%s: received %+v, want the operation to be rejected", tc.name, res)
+      continue
+    }
+    tc.details["code"] = tc.code
+    if ext := res.Errors[0].Extensions; !reflect.DeepEqual(ext, tc.details) {
+      t.Errorf("%s: received the extensions %v, want %v", tc.name, ext, tc.details)
+    }
+  }
+}
+
+func TestQueryLimitsInvalidQuery(t *testing.T) {
+  _, srv := newTestServer(t, func(g *GqlServer) {
+    g.MaxCost = 5
+  })
+
+  // the query which gqlquery can not parse is reported by graphql-go with its location
+  var res testResponse
+  post(t, srv, `{"query": "{ person(id: \"1\") { name }"}`, &res)
+  if len(res.Errors) != 1 || res.Errors[0].Extensions["code"] != nil || !strings.Contains(res.Errors[0].Message, "syntax error") {
+    t.Errorf("received %+v, want the syntax error of graphql-go", res)
+  }
+}
+
+// TestNegativeFirst executes the operation which counts for the default of the multiplier without MaxCost
+func TestNegativeFirst(t *testing.T) {
+  _, srv := newTestServer(t, nil)
+
+  var res testResponse
+  post(t, srv, `{"query": "{ person(id: \"1\") { friends(first: -5) { name } } }"}`, &res)
+  if person, _ := res.Data["person"].(map[string]interface{}); len(res.Errors) > 0 || person == nil || len(person["friends"].([]interface{})) != 0 {
+    t.Errorf("received %+v, want the person without friends", res)
+  }
+}
+
+func TestWebSocketQueryLimits(t *testing.T) {
+  r, srv := newTestServer(t, func(g *GqlServer) {
+    g.MaxCost = 1
+  })
+  conn := dialWebSocket(t, srv)
+
+  subscribe(t, conn, "1", `subscription { personCreated { friends { friends { id } } } }`)
+  msg := readMessage(t, conn)
+  if msg.ID != "1" || msg.Type != wsError || !strings.Contains(string(msg.Payload), `"code":"COST_LIMIT_EXCEEDED"`) {
+    t.Errorf("received %s %s %s, want the subscription to be rejected", msg.ID, msg.Type, msg.Payload)
+  }
+
+  // the subscription within the limit is the only subscriber
+  subscribe(t, conn, "2", `subscription { personCreated { name } }`)
+  waitSubscribers(t, r.pubsub, "personCreated", 1)
+}
+
+func TestMaxDepth(t *testing.T) {
+  r := newTestResolver()
+  srv := serve(t, NewGqlServer(r, "0", nil, graphql.MaxDepth(3)))
+
+  var res testResponse
+  post(t, srv, `{"query": "{ person(id: \"1\") { friends { friends { id } } } }"}`, &res)
+  if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Message, "exceeds max depth 3") {
+    t.Errorf("received %+v, want the depth to be limited", res)
+  }
+}
@@ -0,0 +1,329 @@
+package api
+
+import (
+  "context"
+  "io"
+  "net/http"
+  "net/http/httptest"
+  "os"
+  "os/exec"
+  "path/filepath"
+  "strings"
+  "sync"
+  "testing"
+  "testing/fstest"
+
+  graphql "github.com/graph-gophers/graphql-go"
+)
+
+// getPage sends a GET request of a browser to the endpoint of the server
+func getPage(t *testing.T, srv *httptest.Server) (int, string) {
+  req, _ := http.NewRequest(http.MethodGet, srv.URL+DefaultPath, nil)
+  req.Header.Set("Accept", "text/html,application/xhtml+xml")
+  res, err := http.DefaultClient.Do(req)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer res.Body.Close()
+  body, _ := io.ReadAll(res.Body)
+  return res.StatusCode, string(body)
+}
+
+func TestExplorerDisabledByDefault(t *testing.T) {
+  _, srv := newTestServer(t, nil)
+  if _, body := getPage(t, srv); strings.Contains(body, "<html>") {
+    t.Error("the explorer page is served by default")
+  }
+}
+
+func TestExplorer(t *testing.T) {
+  _, srv := newTestServer(t, func(g *GqlServer) {
+    g.Explorer.Enabled = true
+    g.Explorer.Query = `{ person(id: "1") { name } }</script>`
+  })
+
+  status, body := getPage(t, srv)
+  if status != http.StatusOK || !strings.Contains(body, "<title>GraphQL Explorer</title>") {
+    t.Fatalf("status %d, want the explorer page", status)
+  }
+  if strings.Contains(body, ExplorerConfigPlaceholder) {
+    t.Error("the config placeholder is not replaced")
+  }
+  want := `"query":"{ person(id: \"1\") { name } }\u003c/script\u003e"`
+  if !strings.Contains(body, want) {
+    t.Errorf("the page does not hold the escaped config %s", want)
+  }
+}
+
+func TestExplorerPage(t *testing.T) {
+  _, srv := newTestServer(t, func(g *GqlServer) {
+    g.Explorer.Enabled = true
+    g.Explorer.Title = "API"
+    g.Explorer.Page = "<html><script>start(" + ExplorerConfigPlaceholder + ")</script></html>"
+  })
+
+  _, body := getPage(t, srv)
+  if want := `<html><script>start({"title":"API","query":"","headers":null})</script></html>`; body != want {
+    t.Errorf("the page is %s, want %s", body, want)
+  }
+}
+
+// getAsset sends a GET request of a file of the explorer assets
+func getAsset(t *testing.T, srv *httptest.Server, name string) (*http.Response, string) {
+  res, err := http.Get(srv.URL + DefaultPath + "?" + ExplorerAssetParam + "=" + name)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer res.Body.Close()
+  body, _ := io.ReadAll(res.Body)
+  return res, string(body)
+}
+
+func TestExplorerAssets(t *testing.T) {
+  assets := fstest.MapFS{
+    "index.html":        {Data: []byte(`<html><script src="?explorer=graphiql.min.js"></script><script>render(EXPLORER_CONFIG)</script></html>`)},
+    "graphiql.min.js":   {Data: []byte("var GraphiQL = {};")},
+    "graphiql.min.css":  {Data: []byte(".graphiql-container {}")},
+    "fonts/inter.woff2": {Data: []byte("font")},
+  }
+  var server *GqlServer
+  _, srv := newTestServer(t, func(g *GqlServer) {
+    g.Explorer = ExplorerConfig{Enabled: true, Title: "GraphiQL", Assets: assets}
+    server = g
+  })
+
+  if _, body := getPage(t, srv); body != `<html><script src="?explorer=graphiql.min.js"></script><script>render({"title":"GraphiQL","query":"","headers":null})</script></html>` {
+    t.Errorf("the page is %s, want the index.html of the assets", body)
+  }
+  for name, want := range map[string]string{
+    "graphiql.min.js":   "text/javascript; charset=utf-8",
+    "graphiql.min.css":  "text/css; charset=utf-8",
+    "fonts/inter.woff2": "font/woff2",
+  } {
+    res, body := getAsset(t, srv, name)
+    if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != want || body != string(assets[name].Data) {
+      t.Errorf("%s: got the status %d, the type %q and %q", name, res.StatusCode, res.Header.Get("Content-Type"), body)
+    }
+  }
+  for _, name := range []string{"missing.js", "../server_test.go", "/index.html", "fonts"} {
+    if res, _ := getAsset(t, srv, name); res.StatusCode != http.StatusNotFound {
+      t.Errorf("%s: got the status %d, want 404", name, res.StatusCode)
+    }
+  }
+
+  // the assets are not served when the explorer is disabled
+  server.Explorer.Enabled = false
+  if res, body := getAsset(t, srv, "graphiql.min.js"); res.StatusCode == http.StatusOK && body == string(assets["graphiql.min.js"].Data) {
+    t.Error("the assets are served while the explorer is disabled")
+  }
+}
+
+func TestExplorerAssetsWithoutIndex(t *testing.T) {
+  _, srv := newTestServer(t, func(g *GqlServer) {
+    g.Explorer.Enabled = true
+    g.Explorer.Assets = fstest.MapFS{"graphiql.min.js": {Data: []byte("var GraphiQL = {};")}}
+  })
+  if status, _ := getPage(t, srv); status != http.StatusInternalServerError {
+    t.Errorf("got the status %d, want 500 for the assets without an index.html", status)
+  }
+}
+
+// explorerHarness runs the script of the explorer page in node with the elements of the page it uses,
+// a fetch sending the requests to the server and a fake WebSocket. The steps after the script check its behaviour
+const explorerHarness = `
+var server = process.argv[2];
+var failures = [];
+function check(ok, message) {
+  if (!ok) {
+    failures.push(message);
+  }
+}
+
+function Element(tag) {
+  this.tagName = tag;
+  this.value = "";
+  this.text = "";
+  this.children = [];
+  this.listeners = {};
+  this.disabled = false;
+  var classes = {};
+  this.classList = {
+    toggle: function (c) { classes[c] = !classes[c]; },
+    contains: function (c) { return !!classes[c]; }
+  };
+}
+Object.defineProperty(Element.prototype, "textContent", {
+  get: function () { return this.text + this.children.map(function (c) { return c.textContent; }).join(""); },
+  set: function (v) { this.text = v; this.children = []; }
+});
+Element.prototype.appendChild = function (c) { this.children.push(c); return c; };
+Element.prototype.addEventListener = function (type, f) { this.listeners[type] = f; };
+
+// link returns the first link of the text under the node
+function link(node, text) {
+  if (node.tagName === "a" && node.textContent === text) {
+    return node;
+  }
+  for (var i = 0; i < node.children.length; i++) {
+    var found = link(node.children[i], text);
+    if (found) {
+      return found;
+    }
+  }
+  return null;
+}
+
+var elements = {}, listeners = {};
+global.document = {
+  getElementById: function (id) { return elements[id] || (elements[id] = new Element("#" + id)); },
+  createElement: function (tag) { return new Element(tag); },
+  createTextNode: function (text) { var n = new Element("#text"); n.text = text; return n; },
+  addEventListener: function (type, f) { listeners[type] = f; }
+};
+var el = document.getElementById;
+global.location = new URL(server + "/graphql?variables=" + encodeURIComponent('{"id": "2"}'));
+global.history = { replaceState: function (state, title, url) { global.location = new URL(url); } };
+
+var requests = [], nodeFetch = fetch;
+global.fetch = function (path, init) {
+  requests.push({ path: path, init: init });
+  return nodeFetch(new URL(path, location.href), init);
+};
+
+var sockets = [];
+global.WebSocket = function (url, protocol) {
+  this.url = url;
+  this.protocol = protocol;
+  this.sent = [];
+  sockets.push(this);
+};
+WebSocket.prototype.send = function (data) { this.sent.push(JSON.parse(data)); };
+WebSocket.prototype.close = function (code) { this.closed = code; };
+
+function until(done) {
+  return new Promise(function (resolve, reject) {
+    var start = Date.now();
+    (function poll() {
+      if (done()) {
+        resolve();
+      } else if (Date.now() - start > 5000) {
+        reject(new Error("timeout"));
+      } else {
+        setTimeout(poll, 5);
+      }
+    })();
+  });
+}
+function result() { return el("result").textContent; }
+
+SCRIPT
+
+(async function () {
+  // the config and the url fill the editors
+  check(document.title === "Test API" && el("title").textContent === "Test API", "title " + document.title);
+  check(el("query").value === "query Person($id: ID!) { person(id: $id) { name } }", "query " + el("query").value);
+  check(el("variables").value === '{"id": "2"}', "variables " + el("variables").value);
+  check(JSON.parse(el("headers").value).Authorization === "Bearer token", "headers " + el("headers").value);
+
+  // run posts the query with its variables and headers and shows the response
+  el("run").onclick();
+  await until(function () { return result().indexOf("Person 2") >= 0; });
+  var init = requests[0].init;
+  check(requests[0].path === "/graphql" && init.method === "POST", "request " + requests[0].path + " " + init.method);
+  check(init.headers.Authorization === "Bearer token" && init.headers["Content-Type"] === "application/json", "request headers " + JSON.stringify(init.headers));
+  check(JSON.parse(init.body).variables.id === "2", "request body " + init.body);
+  check(location.searchParams.get("query") === el("query").value, "url " + location.href);
+
+  // ctrl-enter runs the query too, the invalid variables are reported without a request
+  el("variables").value = "{";
+  listeners.keydown({ ctrlKey: true, key: "Enter", preventDefault: function () {} });
+  check(result().indexOf("Invalid variables") === 0 && requests.length === 1, "invalid variables " + result());
+  el("variables").value = "";
+  el("query").value = '{ person(id: "3") { name } }';
+  listeners.keydown({ ctrlKey: true, key: "Enter", preventDefault: function () {} });
+  await until(function () { return result().indexOf("Person 3") >= 0; });
+  check(!location.searchParams.has("variables"), "url with variables " + location.href);
+
+  // tab indents the editors
+  var query = el("query");
+  query.value = "{}";
+  query.selectionStart = query.selectionEnd = 1;
+  query.listeners.keydown({ key: "Tab", target: query, preventDefault: function () {} });
+  check(query.value === "{  }" && query.selectionStart === 3, "tab " + JSON.stringify(query.value));
+
+  // a subscription runs over the websocket, its events shown from the latest
+  query.value = "# events\nsubscription { personCreated { name } }";
+  el("run").onclick();
+  var socket = sockets[0];
+  check(socket && socket.url === server.replace("http", "ws") + "/graphql" && socket.protocol === "graphql-transport-ws", "socket " + (socket && socket.url));
+  check(!el("stop").disabled, "stop disabled while subscribed");
+  socket.onopen();
+  check(socket.sent[0].type === "connection_init" && socket.sent[0].payload.Authorization === "Bearer token", "init " + JSON.stringify(socket.sent[0]));
+  socket.onmessage({ data: JSON.stringify({ type: "connection_ack" }) });
+  check(socket.sent[1].type === "subscribe" && socket.sent[1].id === "1" && socket.sent[1].payload.query === query.value, "subscribe " + JSON.stringify(socket.sent[1]));
+  socket.onmessage({ data: JSON.stringify({ id: "1", type: "next", payload: { data: { personCreated: { name: "A" } } } }) });
+  socket.onmessage({ data: JSON.stringify({ id: "1", type: "next", payload: { data: { personCreated: { name: "B" } } } }) });
+  check(JSON.parse(result())[0].data.personCreated.name === "B", "events " + result());
+  socket.onmessage({ data: JSON.stringify({ type: "ping" }) });
+  check(socket.sent[2].type === "pong", "pong " + JSON.stringify(socket.sent[2]));
+  el("stop").onclick();
+  check(socket.closed === 1000 && el("stop").disabled, "stop " + socket.closed);
+
+  // the docs show the types of the introspection of the schema
+  el("toggle-docs").onclick();
+  await until(function () { return el("docs").textContent.indexOf("query: Query") >= 0; });
+  link(el("docs"), "Person").onclick();
+  var docs = el("docs").textContent;
+  check(docs.indexOf("object Person") >= 0 && docs.indexOf("friends(first: Int, after: ID): [Person]!") >= 0, "docs " + docs);
+
+  if (failures.length) {
+    console.log(failures.join("\n"));
+    process.exit(1);
+  }
+})().catch(function (e) {
+  console.log(e.stack);
+  process.exit(1);
+});
+`
+
+// TestExplorerScript checks the behaviour of the script of the explorer page by running it in node
+func TestExplorerScript(t *testing.T) {
+  if _, err := exec.LookPath("node"); err != nil {
+    t.Skip("node is not available")
+  }
+  var mu sync.Mutex
+  var authorizations []string
+  _, srv := newTestServer(t, func(g *GqlServer) {
+    g.Explorer.Enabled = true
+    g.Explorer.Title = "Test API"
+    g.Explorer.Query = "query Person($id: ID!) { person(id: $id) { name } }"
+    g.Explorer.Headers = map[string]string{"Authorization": "Bearer token"}
+    g.Interceptors = append(g.Interceptors, func(ctx context.Context, req *GqlRequest, next OperationHandler) *graphql.Response {
+      mu.Lock()
+      authorizations = append(authorizations, RequestInfoFrom(ctx).Header.Get("Authorization"))
+      mu.Unlock()
+      return next(ctx, req)
+    })
+  })
+
+  _, page := getPage(t, srv)
+  start, end := strings.Index(page, "<script>"), strings.LastIndex(page, "</script>")
+  if start < 0 || end < start {
+    t.Fatal("the page has no script")
+  }
+  harness := filepath.Join(t.TempDir(), "explorer.js")
+  script := strings.Replace(explorerHarness, "SCRIPT", page[start+len("<script>"):end], 1)
+  if err := os.WriteFile(harness, []byte(script), 0644); err != nil {
+    t.Fatal(err)
+  }
+  if out, err := exec.Command("node", harness, srv.URL).CombinedOutput(); err != nil {
+    t.Fatalf("the explorer script failed: %v\n%s", err, out)
+  }
+
+  // the query, the introspection and the one of ctrl-enter are sent with the headers of the config
+  mu.Lock()
+  defer mu.Unlock()
+  if len(authorizations) != 3 || strings.Join(authorizations, ",") != "Bearer token,Bearer token,Bearer token" {
+    t.Errorf("the server got the authorizations %q, want the header of the config on the 3 operations", authorizations)
+  }
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+  "context"
+  "net"
+  "net/http"
+  "net/url"
+  "strings"
+  "testing"
+  "time"
+
+  "github.com/gorilla/websocket"
+)
+
+func TestCloseWebSockets(t *testing.T) {
+  var g *GqlServer
+  r, srv := newTestServer(t, func(s *GqlServer) { g = s })
+  conn := dialWebSocket(t, srv)
+  subscribe(t, conn, "1", `subscription { personCreated { name } }`)
+  waitSubscribers(t, r.pubsub, "personCreated", 1)
+
+  g.CloseWebSockets()
+  expectClose(t, conn, websocket.CloseGoingAway)
+  waitSubscribers(t, r.pubsub, "personCreated", 0)
+
+  // the new websockets are refused while the http requests are still served
+  dialer := websocket.Dialer{Subprotocols: []string{WebSocketProtocol}}
+  conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+DefaultPath, nil)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer conn.Close()
+  expectClose(t, conn, websocket.CloseGoingAway)
+
+  var res testResponse
+  post(t, srv, `{"query": "{ person(id: \"1\") { name } }"}`, &res)
+  if len(res.Errors) > 0 || res.Data["person"] == nil {
+    t.Errorf("received %+v, want the person", res)
+  }
+}
+
+func TestServerRun(t *testing.T) {
+  // the slow requests signal their arrival and wait for release
+  arrived, release := make(chan struct{}, 1), make(chan struct{})
+  r := newTestResolver()
+  g := NewGqlServer(r, "0", nil)
+  g.Path = "/api/graphql"
+  g.Middlewares = []Middleware{func(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+      if req.URL.Query().Get("slow") != "" {
+        arrived <- struct{}{}
+        <-release
+      }
+      next.ServeHTTP(w, req)
+    })
+  }}
+
+  ln, err := net.Listen("tcp", "127.0.0.1:0")
+  if err != nil {
+    t.Fatal(err)
+  }
+  addr := ln.Addr().String()
+  ln.Close()
+  s := g.NewServer(addr)
+
+  ctx, cancel := context.WithCancel(context.Background())
+  defer cancel()
+  done := make(chan error, 1)
+  go func() { done <- s.Run(ctx) }()
+
+  query := "?query=" + url.QueryEscape(`{ person(id: "1") { name } }`)
+  get := func(path string) (*http.Response, error) {
+    return http.Get("http://" + addr + path)
+  }
+  var res *http.Response
+  for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+    if res, err = get(g.Path + query); err == nil {
+      break
+    }
+    if time.Since(start) > 2*time.Second {
+      t.Fatal(err)
+    }
+  }
+  res.Body.Close()
+  if res.StatusCode != http.StatusOK {
+    t.Errorf("got the status %d on %s, want 200", res.StatusCode, g.Path)
+  }
+  if res, err := get(DefaultPath + query); err != nil || res.StatusCode != http.StatusNotFound {
+    t.Errorf("got %v, %v on %s, want 404", res, err, DefaultPath)
+  }
+
+  dialer := websocket.Dialer{Subprotocols: []string{WebSocketProtocol}}
+  conn, _, err := dialer.Dial("ws://"+addr+g.Path, nil)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer conn.Close()
+  writeMessage(t, conn, wsMessage{Type: wsConnectionInit})
+  readMessage(t, conn)
+  subscribe(t, conn, "1", `subscription { personCreated { name } }`)
+  waitSubscribers(t, r.pubsub, "personCreated", 1)
+
+  // a request in flight when the server shuts down is still answered
+  slow := make(chan int, 1)
+  go func() {
+    res, err := get(g.Path + query + "&slow=1")
+    if err != nil {
+      t.Error(err)
+      slow <- 0
+      return
+    }
+    res.Body.Close()
+    slow <- res.StatusCode
+  }()
+  select {
+  case <-arrived:
+  case <-slow:
+    t.Fatal("the slow request did not reach the server")
+  }
+
+  cancel()
+  // the websockets are closed on shutdown, which cancels their subscriptions
+  expectClose(t, conn, websocket.CloseGoingAway)
+  waitSubscribers(t, r.pubsub, "personCreated", 0)
+
+  select {
+  case err := <-done:
+    t.Fatalf("Run returned %v before the request in flight was answered", err)
+  case <-time.After(50 * time.Millisecond):
+  }
+  close(release)
+  if status := <-slow; status != http.StatusOK {
+    t.Errorf("got the status %d for the request in flight, want 200", status)
+  }
+  if err := <-done; err != nil {
+    t.Errorf("Run returned %v", err)
+  }
+  if _, err := get(g.Path + query); err == nil || !strings.Contains(err.Error(), "connection refused") {
+    t.Errorf("got %v after the shutdown, want the connection to be refused", err)
+  }
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+  "context"
+  "errors"
+  "sync"
+  "testing"
+  "time"
+)
+
+func TestLoaderBatchesAndCaches(t *testing.T) {
+  var mu sync.Mutex
+  var batches [][]string
+  fetchers := Fetchers{
+    Person: func(ctx context.Context, ids []string) ([]*Person, error) {
+      mu.Lock()
+      batches = append(batches, ids)
+      mu.Unlock()
+      people := make([]*Person, len(ids))
+      for i, id := range ids {
+        if id != "unknown" {
+          people[i] = &Person{ID: id}
+        }
+      }
+      return people, nil
+    },
+  }
+  ctx := context.Background()
+  loaders := NewLoaders(ctx, fetchers, LoaderConfig{Wait: 10 * time.Millisecond, MaxBatch: 100})
+
+  var wg sync.WaitGroup
+  for _, id := range []string{"1", "2", "unknown", "1"} {
+    wg.Add(1)
+    go func(id string) {
+      defer wg.Done()
+      p, err := loaders.Person.Load(ctx, id)
+      if err != nil {
+        t.Errorf("load %s: %v", id, err)
+      } else if (p == nil) != (id == "unknown") {
+        t.Errorf("load %s returned %v", id, p)
+      }
+    }(id)
+  }
+  wg.Wait()
+
+  people, err := loaders.Person.LoadAll(ctx, []string{"2", "1"})
+  if err != nil || people[0].ID != "2" || people[1].ID != "1" {
+    t.Errorf("LoadAll returned %v, %v", people, err)
+  }
+  if len(batches) != 1 || len(batches[0]) != 3 {
+    t.Errorf("fetched %v, want a single batch of the 3 ids", batches)
+  }
+}
+
+func TestLoaderDoesNotCacheErrors(t *testing.T) {
+  calls := 0
+  fetchers := Fetchers{
+    Person: func(ctx context.Context, ids []string) ([]*Person, error) {
+      calls++
+      if calls == 1 {
+        return nil, errors.New("backend unavailable")
+      }
+      return []*Person{{ID: ids[0]}}, nil
+    },
+  }
+  ctx := context.Background()
+  loaders := NewLoaders(ctx, fetchers, LoaderConfig{MaxBatch: 1})
+
+  if _, err := loaders.Person.Load(ctx, "1"); err == nil {
+    t.Fatal("the first load did not fail")
+  }
+  p, err := loaders.Person.Load(ctx, "1")
+  if err != nil || p == nil || p.ID != "1" {
+    t.Errorf("the load after the failed fetch returned %v, %v", p, err)
+  }
+  if calls != 2 {
+    t.Errorf("fetched %d times, want 2", calls)
+  }
+}
+
+func TestLoaderDeadline(t *testing.T) {
+  deadlines := make(chan time.Time, 1)
+  fetchers := Fetchers{
+    Person: func(ctx context.Context, ids []string) ([]*Person, error) {
+      d, _ := ctx.Deadline()
+      deadlines <- d
+      <-ctx.Done()
+      return nil, ctx.Err()
+    },
+  }
+  loaders := NewLoaders(context.Background(), fetchers, LoaderConfig{Wait: time.Millisecond})
+
+  ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+  defer cancel()
+  want, _ := ctx.Deadline()
+
+  start := time.Now()
+  if _, err := loaders.Person.Load(ctx, "1"); !errors.Is(err, context.DeadlineExceeded) {
+    t.Errorf("load returned %v, want the deadline to be exceeded", err)
+  }
+  if elapsed := time.Since(start); elapsed > time.Second {
+    t.Errorf("load returned after %v", elapsed)
+  }
+  if d := <-deadlines; !d.Equal(want) {
+    t.Errorf("the fetch got the deadline %v, want %v", d, want)
+  }
+}
+
+func TestEventLoaders(t *testing.T) {
+  var mu sync.Mutex
+  fetched := 0
+  fetchers := Fetchers{
+    Person: func(ctx context.Context, ids []string) ([]*Person, error) {
+      mu.Lock()
+      fetched += len(ids)
+      mu.Unlock()
+      people := make([]*Person, len(ids))
+      for i, id := range ids {
+        people[i] = &Person{ID: id}
+      }
+      return people, nil
+    },
+  }
+  subscription, cancel := context.WithCancel(context.Background())
+  defer cancel()
+  ctx := withEventLoaders(subscription, fetchers, LoaderConfig{Wait: time.Millisecond})
+  scope := ctx.Value(loadersKey{}).(*eventLoaders)
+
+  // event creates the context of an event the way graphql-go does, with a timeout
+  event := func() (context.Context, context.CancelFunc) {
+    return context.WithTimeout(ctx, time.Second)
+  }
+  first, done := event()
+  loaders := LoadersFrom(first)
+  if LoadersFrom(WithRequestInfo(first, &RequestInfo{})) != loaders {
+    t.Error("the resolvers of an event got different loaders")
+  }
+  if _, err := loaders.Person.Load(first, "1"); err != nil {
+    t.Fatal(err)
+  }
+  loaders.Person.Load(first, "1")
+  done()
+
+  second, done := event()
+  defer done()
+  if LoadersFrom(second) == loaders {
+    t.Fatal("the next event got the loaders of the previous one")
+  }
+  if _, err := LoadersFrom(second).Person.Load(second, "1"); err != nil {
+    t.Fatal(err)
+  }
+  if fetched != 2 {
+    t.Errorf("fetched %d people, want the person once per event", fetched)
+  }
+
+  // the loaders of the resolved events are dropped
+  scope.mu.Lock()
+  n := len(scope.loaders)
+  scope.mu.Unlock()
+  if n != 1 {
+    t.Errorf("kept the loaders of %d events, want the ones of the current event only", n)
+  }
+}
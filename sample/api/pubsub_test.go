@@ -0,0 +1,90 @@
+package api
+
+import (
+  "context"
+  "testing"
+  "time"
+)
+
+func TestPubSubSlowSubscriber(t *testing.T) {
+  ps := NewPubSub()
+  ps.Buffer = 2
+  ctx, cancel := context.WithCancel(context.Background())
+  defer cancel()
+
+  slow := ps.Subscribe(ctx, "topic")
+  fast := ps.Subscribe(ctx, "topic")
+
+  received := make(chan interface{}, 10)
+  go func() {
+    for e := range fast {
+      received <- e
+    }
+  }()
+
+  // the slow subscriber never reads, so its events are dropped once its buffer is full
+  done := make(chan int)
+  go func() {
+    dropped := 0
+    for i := 0; i < 5; i++ {
+      dropped += ps.Publish("topic", i)
+      time.Sleep(time.Millisecond)
+    }
+    done <- dropped
+  }()
+
+  select {
+  case dropped := <-done:
+    if dropped != 3 {
+      t.Errorf("dropped %d events, want 3", dropped)
+    }
+  case <-time.After(time.Second):
+    t.Fatal("Publish blocked on the slow subscriber")
+  }
+
+  for i := 0; i < 5; i++ {
+    select {
+    case e := <-received:
+      if e != i {
+        t.Errorf("fast subscriber received %v, want %d", e, i)
+      }
+    case <-time.After(time.Second):
+      t.Fatalf("fast subscriber did not receive event %d", i)
+    }
+  }
+
+  for i := 0; i < 2; i++ {
+    if e := <-slow; e != i {
+      t.Errorf("slow subscriber received %v, want %d", e, i)
+    }
+  }
+}
+
+func TestPubSubUnsubscribe(t *testing.T) {
+  ps := NewPubSub()
+  ctx, cancel := context.WithCancel(context.Background())
+  events := ps.Subscribe(ctx, "topic")
+
+  // publishing concurrently with the unsubscription must not send on the closed channel
+  stop := make(chan struct{})
+  go func() {
+    for {
+      select {
+      case <-stop:
+        return
+      default:
+        ps.Publish("topic", 1)
+      }
+    }
+  }()
+  cancel()
+  for range events {
+  }
+  close(stop)
+
+  ps.mu.RLock()
+  defer ps.mu.RUnlock()
+  if len(ps.topics) != 0 {
+    t.Errorf("topics left after the unsubscription: %d", len(ps.topics))
+  }
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+  "context"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "strings"
+  "sync"
+  "testing"
+)
+
+// requestInfoResolver names the people after the RequestInfo of the operation resolving them
+type requestInfoResolver struct {
+  *testResolver
+}
+
+func (r *requestInfoResolver) Person(ctx context.Context, args QueryPersonArgs) (PersonResolver, error) {
+  info := RequestInfoFrom(ctx)
+  name := fmt.Sprintf("%s|%v|%d|%s", info.OperationName, info.Variables["id"], info.BatchIndex, info.Header.Get("X-Request"))
+  return PersonResolver{R: &Person{ID: args.ID, Name: name}}, nil
+}
+
+// TestRequestInfoOfBatches sends concurrent batches, each operation must see the info of its own request
+func TestRequestInfoOfBatches(t *testing.T) {
+  srv := serve(t, NewGqlServer(&requestInfoResolver{newTestResolver()}, "0", nil))
+
+  const requests, operations = 50, 4
+  var wg sync.WaitGroup
+  for n := 0; n < requests; n++ {
+    wg.Add(1)
+    go func(n int) {
+      defer wg.Done()
+      var ops []string
+      for i := 0; i < operations; i++ {
+        ops = append(ops, fmt.Sprintf(`{"query": "query Op%d($id: ID!) { person(id: $id) { name } }", "operationName": "Op%d", "variables": {"id": "%d-%d"}}`, i, i, n, i))
+      }
+      req, _ := http.NewRequest(http.MethodPost, srv.URL+DefaultPath, strings.NewReader("["+strings.Join(ops, ",")+"]"))
+      req.Header.Set("Content-Type", "application/json")
+      req.Header.Set("X-Request", fmt.Sprint(n))
+      res, err := http.DefaultClient.Do(req)
+      if err != nil {
+        t.Error(err)
+        return
+      }
+      defer res.Body.Close()
+
+      var responses []struct {
+        Data struct {
+          Person struct{ Name string }
+        }
+      }
+      if err := json.NewDecoder(res.Body).Decode(&responses); err != nil {
+        t.Error(err)
+        return
+      }
+      if len(responses) != operations {
+        t.Errorf("request %d: received %d responses, want %d", n, len(responses), operations)
+      }
+      for i, r := range responses {
+        if want := fmt.Sprintf("Op%d|%d-%d|%d|%d", i, n, i, i, n); r.Data.Person.Name != want {
+          t.Errorf("request %d: operation %d saw %q, want %q", n, i, r.Data.Person.Name, want)
+        }
+      }
+    }(n)
+  }
+  wg.Wait()
+}
@@ -1,254 +1,1520 @@
 package api
 
 import (
-  "encoding/json"
-  "errors"
-  "io/ioutil"
-  "net/http"
-  "strings"
-  "sync"
-
-  "github.com/graph-gophers/graphql-go"
-  "github.com/rs/cors"
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dealtap/graphql-gen-go/gqlquery"
+	"github.com/gorilla/websocket"
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/rs/cors"
+)
+
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeGraphQL = "application/graphql"
+	Post               = "POST"
+	Get                = "GET"
+
+	// DefaultPath is the path NewGqlServer serves the schema on
+	DefaultPath = "/graphql"
 )
 
+// the defaults of NewServer
 const (
-  ContentTypeJSON    = "application/json"
-  ContentTypeGraphQL = "application/graphql"
-  Post               = "POST"
-  Get                = "GET"
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultReadTimeout       = 30 * time.Second
+	DefaultWriteTimeout      = 30 * time.Second
+	DefaultIdleTimeout       = 120 * time.Second
+	DefaultShutdownTimeout   = 30 * time.Second
 )
 
 type GqlServer struct {
-  Schema      *graphql.Schema
-  Port        string
-  Request     gqlRequest
-  CorsOptions *cors.Options
-  // TODO add facebook dataloader
-}
-
-func NewGqlServer(res GqlResolver, port string, corsOptions *cors.Options) *GqlServer {
-  return &GqlServer{
-    Schema:      graphql.MustParseSchema(Schema, res),
-    Port:        port,
-    CorsOptions: corsOptions,
-  }
+	Schema *graphql.Schema
+	Port   string
+	// Path is the path the schema is served on, any path when empty
+	Path        string
+	CorsOptions *cors.Options
+	// PersistedQueries stores the automatic persisted queries, they are not supported when it is nil
+	PersistedQueries PersistedQueryStore
+	// Fetchers are the batch functions of the Loaders attached to the context of each request
+	Fetchers     Fetchers
+	LoaderConfig LoaderConfig
+	// Middlewares wrap the http handler of the server, the first one being the outermost
+	Middlewares []Middleware
+	// Interceptors run around the execution of each operation, over http or a websocket, the first one being the outermost
+	Interceptors []Interceptor
+	// MaxQuerySize is the maximum length in bytes of the query of an operation, 0 means no limit
+	MaxQuerySize int
+	// MaxCost is the maximum OperationCost of an operation, 0 means no limit
+	MaxCost int
+	// MaxBatchSize is the maximum number of operations of a batch request, 0 means no limit
+	MaxBatchSize int
+	// BatchConcurrency is the maximum number of operations of a batch executed at once, 0 means no limit
+	BatchConcurrency int
+	// OperationTimeout is the deadline of the context of each operation of a http request, 0 means none
+	OperationTimeout time.Duration
+	// SequentialMutations runs the mutations of a batch one after the other in the order of the request,
+	// the other operations are still executed in parallel
+	SequentialMutations bool
+	// Explorer configures the explorer page served to the browsers
+	Explorer ExplorerConfig
+
+	// wsMu guards the open websockets, which are closed when the server shuts down
+	wsMu       sync.Mutex
+	websockets map[*wsConnection]bool
+	wsClosed   bool
 }
 
+// Middleware wraps the http handler of the server i.e., to authenticate the requests
+type Middleware func(next http.Handler) http.Handler
+
+// OperationHandler executes an operation
+type OperationHandler func(ctx context.Context, req *GqlRequest) *graphql.Response
+
+// Interceptor runs around the execution of an operation, including each operation of a batch and the start of a subscription.
+// It can modify the request before calling next and the response it returns,
+// or reject the operation by returning a response of its own without calling next
+type Interceptor func(ctx context.Context, req *GqlRequest, next OperationHandler) *graphql.Response
+
+// NewGqlServer creates the server of the resolver, the options configure the schema
+// i.e., graphql.MaxDepth and graphql.MaxParallelism to limit the depth and the concurrency of the operations
+func NewGqlServer(res GqlResolver, port string, corsOptions *cors.Options, opts ...graphql.SchemaOpt) *GqlServer {
+	return &GqlServer{
+		Schema:           graphql.MustParseSchema(Schema, res, opts...),
+		Port:             port,
+		Path:             DefaultPath,
+		CorsOptions:      corsOptions,
+		PersistedQueries: NewLRUPersistedQueryStore(DefaultPersistedQueryCacheSize),
+		LoaderConfig:     DefaultLoaderConfig,
+		Explorer:         DefaultExplorerConfig,
+	}
+}
+
+// Serve serves the schema on Port with the defaults of NewServer until the server fails
 func (g *GqlServer) Serve() error {
+	return g.NewServer(":" + g.Port).ListenAndServe()
+}
 
-  // TODO should we validate required fields of GqlServer
-  addr := ":" + g.Port
-  srv := &httpServer{g}
-  mux := http.NewServeMux()
-
-  // configure pre-flight/cors request handler
-  var c *cors.Cors
-  if g.CorsOptions == nil {
-    c = cors.AllowAll()
-  } else {
-    c = cors.New(*g.CorsOptions)
-  }
+// Handler returns the http handler of the schema behind the cors checks and the middlewares,
+// so it can be mounted on an existing router. It serves Path only, unless Path is empty
+func (g *GqlServer) Handler() http.Handler {
+
+	// configure pre-flight/cors request handler
+	var c *cors.Cors
+	if g.CorsOptions == nil {
+		c = cors.AllowAll()
+	} else {
+		c = cors.New(*g.CorsOptions)
+	}
 
-  mux.Handle("/graphql", srv)
-  handler := c.Handler(mux)
-  return http.ListenAndServe(addr, handler)
+	srv := &httpServer{
+		GqlServer: g,
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{WebSocketProtocol},
+			// the upgrade request is not subject to cors, so check its origin with the same options
+			CheckOrigin: func(r *http.Request) bool {
+				return r.Header.Get("Origin") == "" || c.OriginAllowed(r)
+			},
+		},
+	}
+
+	// the middlewares run after the cors checks, so pre-flight requests get through
+	var handler http.Handler = srv
+	for i := len(g.Middlewares) - 1; i >= 0; i-- {
+		handler = g.Middlewares[i](handler)
+	}
+
+	if g.Path != "" {
+		mux := http.NewServeMux()
+		mux.Handle(g.Path, handler)
+		handler = mux
+	}
+	return c.Handler(handler)
+}
+
+// Server serves a GqlServer. The embedded http.Server sets its address, TLS config and timeouts
+type Server struct {
+	*http.Server
+	// CertFile and KeyFile are the certificate and the key ListenAndServe serves TLS with,
+	// they are not needed when the TLSConfig holds the certificates
+	CertFile string
+	KeyFile  string
+	// ShutdownTimeout is how long Run waits for the requests in flight to finish, 0 means no limit
+	ShutdownTimeout time.Duration
+}
+
+// NewServer creates the server of the schema on addr with the default timeouts.
+// Shutting it down closes the websockets of the subscriptions, which http.Server leaves open
+func (g *GqlServer) NewServer(addr string) *Server {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           g.Handler(),
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+		ReadTimeout:       DefaultReadTimeout,
+		WriteTimeout:      DefaultWriteTimeout,
+		IdleTimeout:       DefaultIdleTimeout,
+	}
+	srv.RegisterOnShutdown(g.CloseWebSockets)
+	return &Server{
+		Server:          srv,
+		ShutdownTimeout: DefaultShutdownTimeout,
+	}
+}
+
+// ListenAndServe serves TLS when the server has a certificate, plain http otherwise
+func (s *Server) ListenAndServe() error {
+	tlsConfig := s.TLSConfig
+	if s.CertFile != "" || (tlsConfig != nil && (len(tlsConfig.Certificates) > 0 || tlsConfig.GetCertificate != nil)) {
+		return s.Server.ListenAndServeTLS(s.CertFile, s.KeyFile)
+	}
+	return s.Server.ListenAndServe()
+}
+
+// Run serves until ctx is done, i.e., on SIGTERM with signal.NotifyContext, then shuts the server down gracefully:
+// it stops accepting requests and waits up to ShutdownTimeout for the ones in flight
+func (s *Server) Run(ctx context.Context) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx := context.Background()
+	if s.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, s.ShutdownTimeout)
+		defer cancel()
+	}
+	return s.Shutdown(shutdownCtx)
 }
 
 type httpServer struct {
-  *GqlServer
+	*GqlServer
+	upgrader websocket.Upgrader
 }
 
 func (h *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
-  if isContentSupported(r.Header.Get("Content-Type")) == false {
-    http.Error(w, "GraphQL only supports json and graphql content type.", http.StatusBadRequest)
-    return
-  }
+	// subscriptions are served over a websocket on the same endpoint
+	if websocket.IsWebSocketUpgrade(r) {
+		h.serveWebSocket(w, r)
+		return
+	}
 
-  req, htpErr := parse(r)
-  if htpErr != nil {
-    http.Error(w, htpErr.message, htpErr.status)
-    return
-  }
+	// a browser navigating to the endpoint gets the explorer page, which loads the files of its assets
+	if r.Method == Get && h.Explorer.Enabled {
+		if name := r.URL.Query().Get(ExplorerAssetParam); name != "" && h.Explorer.Assets != nil {
+			h.serveExplorerAsset(w, r, name)
+			return
+		}
+		if acceptsHTML(r) {
+			h.serveExplorer(w)
+			return
+		}
+	}
 
-  numReqs := len(req.requests)
-  responses := make([]*graphql.Response, numReqs)
-
-  // Use the WaitGroup to wait for all executions to finish
-  // TODO handle facebook data loader
-  var wg sync.WaitGroup
-  wg.Add(numReqs)
-
-  // iterate over all parsed requests and use go routine to process them in parallel
-  for i, q := range req.requests {
-    go func(i int, q gqlRequest) {
-      h.Request = q
-      res := h.Schema.Exec(r.Context(), q.Query, q.OpName, q.Variables)
-      // FIXME expand returned errors to handle a resolver returning more than one error
-      responses[i] = res
-      wg.Done()
-    }(i, q)
-  }
+	if r.Method == Post && isContentSupported(r.Header.Get("Content-Type")) == false {
+		http.Error(w, "GraphQL only supports json and graphql content type.", http.StatusBadRequest)
+		return
+	}
 
-  wg.Wait()
+	req, htpErr := parse(r)
+	if htpErr != nil {
+		http.Error(w, htpErr.message, htpErr.status)
+		return
+	}
 
-  // TODO should we log errors?
+	numReqs := len(req.requests)
+	if h.MaxBatchSize > 0 && numReqs > h.MaxBatchSize {
+		http.Error(w, fmt.Sprintf("Batch of %d operations exceeds the maximum of %d.", numReqs, h.MaxBatchSize), http.StatusBadRequest)
+		return
+	}
+	responses := make([]*graphql.Response, numReqs)
 
-  var err error
-  var resp []byte
-  /**
-   * at this point there should be at least one response.
-   * in case of batch, we send a json array object otherwise a single json object
-   */
-  if req.batch {
-    resp, err = json.Marshal(responses)
-  } else {
-    resp, err = json.Marshal(responses[0])
-  }
-  if err != nil {
-    http.Error(w, "Server error", http.StatusInternalServerError)
-    return
-  }
+	// the loaders batch the loads of all the operations of the request
+	ctx := WithLoaders(r.Context(), NewLoaders(r.Context(), h.Fetchers, h.LoaderConfig))
+
+	/**
+	 * the operations are executed in parallel by a pool of BatchConcurrency workers.
+	 * A job is an operation, except for the mutations of a batch which are a single job
+	 * running them in order when SequentialMutations is set
+	 */
+	var jobs [][]int
+	mutations := -1
+	for i := range req.requests {
+		q := &req.requests[i]
+		// a request of a persisted query may only have its hash
+		if res := h.resolvePersistedQuery(ctx, q); res != nil {
+			responses[i] = res
+			continue
+		}
+		if h.SequentialMutations && req.batch && isMutation(q) {
+			if mutations < 0 {
+				mutations = len(jobs)
+				jobs = append(jobs, nil)
+			}
+			jobs[mutations] = append(jobs[mutations], i)
+			continue
+		}
+		jobs = append(jobs, []int{i})
+	}
+
+	queue := make(chan []int, len(jobs))
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+
+	workers := len(jobs)
+	if h.BatchConcurrency > 0 && h.BatchConcurrency < workers {
+		workers = h.BatchConcurrency
+	}
+
+	// Use the WaitGroup to wait for all executions to finish
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for n := 0; n < workers; n++ {
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				for _, i := range job {
+					// FIXME expand returned errors to handle a resolver returning more than one error
+					responses[i] = h.executeOperation(ctx, r, i, &req.requests[i])
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// TODO should we log errors?
+
+	var err error
+	var resp []byte
+	/**
+	 * at this point there should be at least one response.
+	 * in case of batch, we send a json array object otherwise a single json object
+	 */
+	if req.batch {
+		resp, err = json.Marshal(responses)
+	} else {
+		resp, err = json.Marshal(responses[0])
+	}
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp)
+}
+
+// executeOperation executes the i-th operation of the request r within OperationTimeout
+func (h *httpServer) executeOperation(ctx context.Context, r *http.Request, i int, q *GqlRequest) *graphql.Response {
+	if h.OperationTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.OperationTimeout)
+		defer cancel()
+	}
+	info := &RequestInfo{
+		OperationName: q.OpName,
+		Variables:     q.Variables,
+		BatchIndex:    i,
+		Header:        r.Header,
+		RemoteAddr:    r.RemoteAddr,
+	}
+	return h.execute(WithRequestInfo(ctx, info), q)
+}
+
+// isMutation tells whether the operation of the request is a mutation, an invalid query being left to graphql-go
+func isMutation(q *GqlRequest) bool {
+	doc, err := gqlquery.Parse(q.Query)
+	if err != nil {
+		return false
+	}
+	op := doc.Operation(q.OpName)
+	return op != nil && op.Kind == "mutation"
+}
+
+// RequestInfo describes an operation being executed and the http request it was sent with.
+// It is shared by the resolvers of the operation, which must not modify it
+type RequestInfo struct {
+	OperationName string
+	Variables     map[string]interface{}
+	// BatchIndex is the index of the operation in a batch request, 0 otherwise
+	BatchIndex int
+	// Header is the header of the http request, or of the websocket upgrade request of a subscription
+	Header     http.Header
+	RemoteAddr string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a copy of the context holding the request info
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFrom returns the request info of the operation of the context, or nil outside of a request of GqlServer
+func RequestInfoFrom(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info
+}
+
+// execute runs the operation through the interceptors
+func (g *GqlServer) execute(ctx context.Context, req *GqlRequest) *graphql.Response {
+	return g.intercept(func(ctx context.Context, req *GqlRequest) *graphql.Response {
+		if res := g.checkLimits(req); res != nil {
+			return res
+		}
+		return g.Schema.Exec(ctx, req.Query, req.OpName, req.Variables)
+	})(ctx, req)
+}
+
+// intercept wraps the handler in the interceptors
+func (g *GqlServer) intercept(handler OperationHandler) OperationHandler {
+	for i := len(g.Interceptors) - 1; i >= 0; i-- {
+		interceptor, next := g.Interceptors[i], handler
+		handler = func(ctx context.Context, req *GqlRequest) *graphql.Response {
+			return interceptor(ctx, req, next)
+		}
+	}
+	return handler
+}
+
+// checkLimits returns an error response when the operation exceeds MaxQuerySize or MaxCost,
+// or when its cost can not be computed while MaxCost is set
+func (g *GqlServer) checkLimits(req *GqlRequest) *graphql.Response {
+	if size := len(req.Query); g.MaxQuerySize > 0 && size > g.MaxQuerySize {
+		message := fmt.Sprintf("query of %d bytes exceeds the maximum size of %d bytes", size, g.MaxQuerySize)
+		return errorResponse(message, "QUERY_TOO_LARGE", map[string]interface{}{"size": size, "maxSize": g.MaxQuerySize})
+	}
+	if g.MaxCost > 0 {
+		cost, err := OperationCost(req.Query, req.OpName, req.Variables)
+		if err != nil {
+			// graphql-go reports the errors of an invalid query with their location, the other queries
+			// are rejected as their cost is unknown
+			if errs := g.Schema.ValidateWithVariables(req.Query, req.Variables); len(errs) > 0 {
+				return &graphql.Response{Errors: errs}
+			}
+			return errorResponse("unable to compute the cost of the operation: "+err.Error(), "COST_ANALYSIS_FAILED", nil)
+		}
+		if cost > g.MaxCost {
+			message := fmt.Sprintf("operation cost of %d exceeds the maximum cost of %d", cost, g.MaxCost)
+			return errorResponse(message, "COST_LIMIT_EXCEEDED", map[string]interface{}{"cost": cost, "maxCost": g.MaxCost})
+		}
+	}
+	return nil
+}
 
-  w.Header().Set("Content-Type", ContentTypeJSON)
-  w.WriteHeader(http.StatusOK)
-  w.Write(resp)
+// errorResponse returns the response of an operation rejected before its execution,
+// the code and the details of the error are set in its extensions
+func errorResponse(message, code string, details map[string]interface{}) *graphql.Response {
+	extensions := map[string]interface{}{"code": code}
+	for k, v := range details {
+		extensions[k] = v
+	}
+	err := &gqlerrors.QueryError{
+		Message:    message,
+		Extensions: extensions,
+	}
+	return &graphql.Response{Errors: []*gqlerrors.QueryError{err}}
 }
 
 func isContentSupported(contentType string) bool {
-  return strings.HasPrefix(contentType, ContentTypeJSON) || strings.HasPrefix(contentType, ContentTypeGraphQL)
+	return strings.HasPrefix(contentType, ContentTypeJSON) || strings.HasPrefix(contentType, ContentTypeGraphQL)
 }
 
 type request struct {
-  requests []gqlRequest
-  batch    bool
+	requests []GqlRequest
+	batch    bool
 }
 
-type gqlRequest struct {
-  Query     string                 `json:"query"`
-  OpName    string                 `json:"operationName"`
-  Variables map[string]interface{} `json:"variables"`
+// GqlRequest is an operation of a request
+type GqlRequest struct {
+	Query      string                 `json:"query"`
+	OpName     string                 `json:"operationName"`
+	Variables  map[string]interface{} `json:"variables"`
+	Extensions *gqlExtensions         `json:"extensions"`
 }
 
 type httpError struct {
-  status  int
-  message string
-  error
+	status  int
+	message string
+	error
 }
 
 func parse(r *http.Request) (*request, *httpError) {
 
-  if r.Method == Get {
-    return parseGet(r)
-  } else if r.Method == Post {
-    return parsePost(r)
-  }
+	if r.Method == Get {
+		return parseGet(r)
+	} else if r.Method == Post {
+		return parsePost(r)
+	}
 
-  return nil, &httpError{
-    status:  http.StatusMethodNotAllowed,
-    message: "GraphQL only supports POST and GET requests.",
-    error:   errors.New(r.Method + " is not allowed"),
-  }
+	return nil, &httpError{
+		status:  http.StatusMethodNotAllowed,
+		message: "GraphQL only supports POST and GET requests.",
+		error:   errors.New(r.Method + " is not allowed"),
+	}
 }
 
 func parseGet(r *http.Request) (*request, *httpError) {
 
-  v := r.URL.Query()
-  var (
-    queries   = v["query"]
-    opNames   = v["operationName"]
-    variables = v["variables"]
-    qLen      = len(queries)
-    nLen      = len(opNames)
-    vLen      = len(variables)
-  )
-
-  if qLen == 0 {
-    return nil, &httpError{
-      status:  http.StatusBadRequest,
-      message: "Missing request parameters",
-      error:   errors.New("missing request parameters"),
-    }
-  }
+	v := r.URL.Query()
+	var (
+		queries    = v["query"]
+		opNames    = v["operationName"]
+		variables  = v["variables"]
+		extensions = v["extensions"]
+		qLen       = len(queries)
+		nLen       = len(opNames)
+		vLen       = len(variables)
+		eLen       = len(extensions)
+	)
+
+	// a persisted query can be requested with the extensions only
+	if eLen > qLen {
+		qLen = eLen
+	}
+
+	if qLen == 0 {
+		return nil, &httpError{
+			status:  http.StatusBadRequest,
+			message: "Missing request parameters",
+			error:   errors.New("missing request parameters"),
+		}
+	}
+
+	requests := make([]GqlRequest, 0, qLen)
+
+	// This loop assumes there will be a corresponding element at each index
+	// for query, operation name, variable and extension fields.
+	// TODO maybe we should do some validation?
+	for i := 0; i < qLen; i++ {
+		var q, opName string
+
+		if i < len(queries) {
+			q = queries[i]
+		}
+
+		if i < nLen {
+			opName = opNames[i]
+		}
+
+		var m = map[string]interface{}{}
+		if i < vLen {
+			variable := variables[i]
+			if err := json.Unmarshal([]byte(variable), &m); err != nil {
+				return nil, &httpError{
+					status:  http.StatusBadRequest,
+					message: "Unable to read variables.",
+					error:   err,
+				}
+			}
+		}
+
+		var ext *gqlExtensions
+		if i < eLen {
+			if err := json.Unmarshal([]byte(extensions[i]), &ext); err != nil {
+				return nil, &httpError{
+					status:  http.StatusBadRequest,
+					message: "Unable to read extensions.",
+					error:   err,
+				}
+			}
+		}
+
+		requests = append(requests, GqlRequest{
+			Query:      q,
+			OpName:     opName,
+			Variables:  m,
+			Extensions: ext,
+		})
+	}
+
+	return &request{requests: requests, batch: qLen > 1}, nil
+}
+
+func parsePost(r *http.Request) (*request, *httpError) {
+
+	readBodyErr := &httpError{
+		status:  http.StatusBadRequest,
+		message: "Unable to read body.",
+	}
+
+	// read and close the body
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		readBodyErr.error = err
+		return nil, readBodyErr
+	}
+	r.Body.Close()
+
+	if len(body) == 0 {
+		return nil, &httpError{
+			status:  http.StatusBadRequest,
+			message: "Missing request body.",
+			error:   errors.New("missing request body"),
+		}
+	}
+
+	var requests []GqlRequest
+	batch := false
+
+	// Graphql content type request will send only one query
+	if strings.HasPrefix(r.Header.Get("Content-Type"), ContentTypeGraphQL) {
+		req := GqlRequest{}
+		req.Query = string(body)
+		requests = append(requests, req)
+	} else {
+		// Inspect the first character to inform how the body is parsed.
+		trimmed := bytes.TrimLeft(body, " \t\r\n")
+		if len(trimmed) == 0 {
+			return nil, &httpError{
+				status:  http.StatusBadRequest,
+				message: "Missing request body.",
+				error:   errors.New("missing request body"),
+			}
+		}
+		switch trimmed[0] {
+		case '{':
+			req := GqlRequest{}
+			if err := json.Unmarshal(body, &req); err != nil {
+				readBodyErr.error = err
+				return nil, readBodyErr
+			}
+			requests = append(requests, req)
+		case '[':
+			if err := json.Unmarshal(body, &requests); err != nil {
+				readBodyErr.error = err
+				return nil, readBodyErr
+			}
+			if len(requests) == 0 {
+				return nil, &httpError{
+					status:  http.StatusBadRequest,
+					message: "Empty batch.",
+					error:   errors.New("empty batch"),
+				}
+			}
+			batch = true
+		default:
+			return nil, &httpError{
+				status:  http.StatusBadRequest,
+				message: "Request body must be a json object or array.",
+				error:   errors.New("request body is not a json object or array"),
+			}
+		}
+	}
+
+	return &request{requests: requests, batch: batch}, nil
+}
+
+// WebSocketProtocol is the websocket sub-protocol of the subscriptions,
+// see https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const WebSocketProtocol = "graphql-transport-ws"
+
+const (
+	wsConnectionInit = "connection_init"
+	wsConnectionAck  = "connection_ack"
+	wsPing           = "ping"
+	wsPong           = "pong"
+	wsSubscribe      = "subscribe"
+	wsNext           = "next"
+	wsError          = "error"
+	wsComplete       = "complete"
+
+	// wsInitTimeout is how long a client has to send the connection_init message after connecting
+	wsInitTimeout = 10 * time.Second
+	// wsWriteTimeout is how long a message can take to be written before the connection is closed
+	wsWriteTimeout = 10 * time.Second
+)
 
-  requests := make([]gqlRequest, 0, qLen)
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsConnection runs the operations subscribed by a websocket client
+type wsConnection struct {
+	*GqlServer
+	conn *websocket.Conn
+	ctx  context.Context
+	// r is the upgrade request of the connection
+	r *http.Request
+
+	// writeMu serializes the messages written by the operations
+	writeMu sync.Mutex
+
+	mu         sync.Mutex
+	acked      bool
+	operations map[string]*wsOperation
+}
+
+type wsOperation struct {
+	cancel context.CancelFunc
+}
+
+func (h *httpServer) serveWebSocket(w http.ResponseWriter, r *http.Request) {
 
-  // This loop assumes there will be a corresponding element at each index
-  // for query, operation name, and variable fields.
-  // TODO maybe we should do some validation?
-  for i, q := range queries {
-    var opName string
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already replied with an http error
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	c := &wsConnection{
+		GqlServer:  h.GqlServer,
+		conn:       conn,
+		ctx:        ctx,
+		r:          r,
+		operations: map[string]*wsOperation{},
+	}
+
+	if conn.Subprotocol() != WebSocketProtocol {
+		c.close(4406, "Subprotocol not acceptable")
+		return
+	}
+
+	if !h.addWebSocket(c) {
+		c.close(websocket.CloseGoingAway, "Server shutting down")
+		return
+	}
+	defer h.removeWebSocket(c)
+
+	initTimer := time.AfterFunc(wsInitTimeout, func() {
+		if !c.isAcked() {
+			c.close(4408, "Connection initialisation timeout")
+		}
+	})
+	defer initTimer.Stop()
+
+	c.serve()
+}
+
+// addWebSocket registers the connection to be closed on shutdown, unless the server is already shutting down
+func (g *GqlServer) addWebSocket(c *wsConnection) bool {
+	g.wsMu.Lock()
+	defer g.wsMu.Unlock()
+
+	if g.wsClosed {
+		return false
+	}
+	if g.websockets == nil {
+		g.websockets = map[*wsConnection]bool{}
+	}
+	g.websockets[c] = true
+	return true
+}
+
+func (g *GqlServer) removeWebSocket(c *wsConnection) {
+	g.wsMu.Lock()
+	defer g.wsMu.Unlock()
+	delete(g.websockets, c)
+}
+
+// CloseWebSockets closes the websockets of the subscriptions and refuses new ones, which cancels their operations.
+// Server does it on shutdown, a http.Server serving Handler can do it with RegisterOnShutdown
+func (g *GqlServer) CloseWebSockets() {
+	g.wsMu.Lock()
+	g.wsClosed = true
+	websockets := g.websockets
+	g.websockets = nil
+	g.wsMu.Unlock()
+
+	for c := range websockets {
+		c.close(websocket.CloseGoingAway, "Server shutting down")
+	}
+}
+
+// serve reads the messages of the client until the connection is closed
+func (c *wsConnection) serve() {
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Type == "" {
+			c.close(4400, "Invalid message received")
+			return
+		}
+
+		switch msg.Type {
+		case wsConnectionInit:
+			c.mu.Lock()
+			acked := c.acked
+			c.acked = true
+			c.mu.Unlock()
+			if acked {
+				c.close(4429, "Too many initialisation requests")
+				return
+			}
+			c.write(wsMessage{Type: wsConnectionAck})
+		case wsPing:
+			c.write(wsMessage{Type: wsPong, Payload: msg.Payload})
+		case wsPong:
+		case wsSubscribe:
+			if !c.isAcked() {
+				c.close(4401, "Unauthorized")
+				return
+			}
+			var req GqlRequest
+			if err := json.Unmarshal(msg.Payload, &req); err != nil || msg.ID == "" {
+				c.close(4400, "Invalid message received")
+				return
+			}
+			if !c.subscribe(msg.ID, req) {
+				c.close(4409, "Subscriber for "+msg.ID+" already exists")
+				return
+			}
+		case wsComplete:
+			c.stop(msg.ID)
+		default:
+			c.close(4400, "Invalid message received")
+			return
+		}
+	}
+}
+
+func (c *wsConnection) isAcked() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.acked
+}
 
-    if i < nLen {
-      opName = opNames[i]
+// subscribe starts the operation unless another one with the same id is running
+func (c *wsConnection) subscribe(id string, req GqlRequest) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.operations[id]; exists {
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	ctx = withEventLoaders(ctx, c.Fetchers, c.LoaderConfig)
+	ctx = WithRequestInfo(ctx, &RequestInfo{
+		OperationName: req.OpName,
+		Variables:     req.Variables,
+		Header:        c.r.Header,
+		RemoteAddr:    c.r.RemoteAddr,
+	})
+	op := &wsOperation{cancel}
+	c.operations[id] = op
+
+	go c.execute(ctx, id, op, req)
+	return true
+}
+
+// stop cancels the operation when the client is no longer interested in its results
+func (c *wsConnection) stop(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if op, ok := c.operations[id]; ok {
+		op.cancel()
+		delete(c.operations, id)
+	}
+}
+
+// execute sends the results of the operation to the client.
+// Queries and mutations send a single result, subscriptions one per event
+func (c *wsConnection) execute(ctx context.Context, id string, op *wsOperation, req GqlRequest) {
+
+	defer func() {
+		op.cancel()
+		c.mu.Lock()
+		if c.operations[id] == op {
+			delete(c.operations, id)
+		}
+		c.mu.Unlock()
+	}()
+
+	// the interceptors run around the start of the operation and get its first result,
+	// the other results of a subscription are sent as they come
+	var responses <-chan interface{}
+	ended := false
+	first := c.intercept(func(ctx context.Context, req *GqlRequest) *graphql.Response {
+		if res := c.checkLimits(req); res != nil {
+			return res
+		}
+		var err error
+		responses, err = c.Schema.Subscribe(ctx, req.Query, req.OpName, req.Variables)
+		if err != nil {
+			return &graphql.Response{Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("%s", err)}}
+		}
+		r, ok := <-responses
+		if !ok {
+			ended = true
+			return &graphql.Response{}
+		}
+		return r.(*graphql.Response)
+	})(ctx, &req)
+
+	failed := false
+	handle := func(res *graphql.Response) {
+		// keep draining the responses so the goroutines of graphql-go can finish
+		if failed {
+			return
+		}
+		// a response without data is an error raised before the operation could be executed
+		if res.Data == nil && len(res.Errors) > 0 {
+			failed = true
+			op.cancel()
+			c.send(id, wsError, res.Errors)
+			return
+		}
+		c.send(id, wsNext, res)
+	}
+	if first != nil && !ended {
+		handle(first)
+	}
+	if responses != nil {
+		for r := range responses {
+			handle(r.(*graphql.Response))
+		}
+	}
+
+	// the client does not expect a complete message for an operation it completed itself
+	if !failed && ctx.Err() == nil {
+		c.write(wsMessage{ID: id, Type: wsComplete})
+	}
+}
+
+func (c *wsConnection) send(id, typ string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		c.close(websocket.CloseInternalServerErr, "Unable to marshal the response")
+		return
+	}
+	c.write(wsMessage{ID: id, Type: typ, Payload: data})
+}
+
+func (c *wsConnection) write(msg wsMessage) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := c.conn.WriteJSON(msg); err != nil {
+		// stops the read loop which cancels the running operations
+		c.conn.Close()
+	}
+}
+
+// close closes the connection with one of the close codes of the protocol
+func (c *wsConnection) close(code int, reason string) {
+	msg := websocket.FormatCloseMessage(code, reason)
+	c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteTimeout))
+	c.conn.Close()
+}
+
+// DefaultPubSubBuffer is the Buffer of NewPubSub
+const DefaultPubSubBuffer = 64
+
+// PubSub is an in-memory publish/subscribe hub.
+// Mutations can publish events to a topic which are received by the subscription resolvers subscribed to it
+type PubSub struct {
+	// Buffer is how many events a subscriber can lag behind, the events published to a subscriber
+	// whose buffer is full are dropped so that a slow subscriber does not block the publishers
+	Buffer int
+
+	mu     sync.RWMutex
+	topics map[string]map[*pubSubscriber]struct{}
+}
+
+type pubSubscriber struct {
+	events chan interface{}
+
+	// mu guards the sends to events against its closing
+	mu     sync.Mutex
+	closed bool
+}
+
+func NewPubSub() *PubSub {
+	return &PubSub{
+		Buffer: DefaultPubSubBuffer,
+		topics: map[string]map[*pubSubscriber]struct{}{},
+	}
+}
+
+// Subscribe returns a channel receiving the events published to the topic.
+// The channel is closed once ctx is done
+func (ps *PubSub) Subscribe(ctx context.Context, topic string) <-chan interface{} {
+	s := &pubSubscriber{
+		events: make(chan interface{}, ps.Buffer),
+	}
+
+	ps.mu.Lock()
+	if ps.topics[topic] == nil {
+		ps.topics[topic] = map[*pubSubscriber]struct{}{}
+	}
+	ps.topics[topic][s] = struct{}{}
+	ps.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		ps.mu.Lock()
+		delete(ps.topics[topic], s)
+		if len(ps.topics[topic]) == 0 {
+			delete(ps.topics, topic)
+		}
+		ps.mu.Unlock()
+
+		s.mu.Lock()
+		s.closed = true
+		close(s.events)
+		s.mu.Unlock()
+	}()
+
+	return s.events
+}
+
+// Publish sends the event to the subscribers of the topic without waiting for them to receive it.
+// It returns the number of subscribers the event was dropped for as their buffer was full
+func (ps *PubSub) Publish(topic string, event interface{}) (dropped int) {
+	ps.mu.RLock()
+	subscribers := make([]*pubSubscriber, 0, len(ps.topics[topic]))
+	for s := range ps.topics[topic] {
+		subscribers = append(subscribers, s)
+	}
+	ps.mu.RUnlock()
+
+	for _, s := range subscribers {
+		if !s.send(event) {
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// send queues the event unless the buffer of the subscriber is full
+func (s *pubSubscriber) send(event interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return true
+	}
+	select {
+	case s.events <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// DefaultPersistedQueryCacheSize is the number of queries kept by the store of NewGqlServer
+const DefaultPersistedQueryCacheSize = 1000
+
+// PersistedQueryStore stores the automatic persisted queries by their sha256 hash.
+// It can be backed by a shared cache when the server runs on more than one instance
+type PersistedQueryStore interface {
+	Get(ctx context.Context, hash string) (query string, ok bool)
+	Add(ctx context.Context, hash string, query string)
+}
+
+// LRUPersistedQueryStore is an in-memory PersistedQueryStore which evicts the least recently used queries
+type LRUPersistedQueryStore struct {
+	mu   sync.Mutex
+	size int
+	// order lists the queries from the most to the least recently used
+	order   *list.List
+	queries map[string]*list.Element
+}
+
+type persistedQueryEntry struct {
+	hash  string
+	query string
+}
+
+// NewLRUPersistedQueryStore creates a store keeping up to size queries
+func NewLRUPersistedQueryStore(size int) *LRUPersistedQueryStore {
+	return &LRUPersistedQueryStore{
+		size:    size,
+		order:   list.New(),
+		queries: map[string]*list.Element{},
+	}
+}
+
+func (s *LRUPersistedQueryStore) Get(ctx context.Context, hash string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.queries[hash]
+	if !ok {
+		return "", false
+	}
+	s.order.MoveToFront(e)
+	return e.Value.(*persistedQueryEntry).query, true
+}
+
+func (s *LRUPersistedQueryStore) Add(ctx context.Context, hash string, query string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.queries[hash]; ok {
+		s.order.MoveToFront(e)
+		return
+	}
+	s.queries[hash] = s.order.PushFront(&persistedQueryEntry{hash: hash, query: query})
+	for s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.queries, oldest.Value.(*persistedQueryEntry).hash)
+	}
+}
+
+type gqlExtensions struct {
+	PersistedQuery *persistedQuery `json:"persistedQuery,omitempty"`
+}
+
+// persistedQuery is the `persistedQuery` extension of a request of the Apollo APQ protocol
+type persistedQuery struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+// resolvePersistedQuery looks up the query of a request sent with its hash only, or stores the query of a request
+// sent with both once it passes the limits and the validation. An error response is returned when the query is unknown,
+// so that the client sends it again in full
+func (g *GqlServer) resolvePersistedQuery(ctx context.Context, q *GqlRequest) *graphql.Response {
+	if q.Extensions == nil || q.Extensions.PersistedQuery == nil {
+		return nil
+	}
+	if g.PersistedQueries == nil {
+		return errorResponse("PersistedQueryNotSupported", "PERSISTED_QUERY_NOT_SUPPORTED", nil)
+	}
+
+	pq := q.Extensions.PersistedQuery
+	hash := strings.ToLower(pq.Sha256Hash)
+	if pq.Version != 1 {
+		return errorResponse("Unsupported persisted query version", "PERSISTED_QUERY_VERSION_NOT_SUPPORTED", nil)
+	}
+
+	if q.Query == "" {
+		query, ok := g.PersistedQueries.Get(ctx, hash)
+		if !ok {
+			return errorResponse("PersistedQueryNotFound", "PERSISTED_QUERY_NOT_FOUND", nil)
+		}
+		q.Query = query
+		return nil
+	}
+
+	sum := sha256.Sum256([]byte(q.Query))
+	if hex.EncodeToString(sum[:]) != hash {
+		return errorResponse("provided sha does not match query", "INVALID_PERSISTED_QUERY_HASH", nil)
+	}
+	// an invalid query is not stored, its execution reports the errors
+	if g.checkLimits(q) == nil && len(g.Schema.ValidateWithVariables(q.Query, q.Variables)) == 0 {
+		g.PersistedQueries.Add(ctx, hash, q.Query)
+	}
+	return nil
+}
+
+// ExplorerConfig configures the explorer page served on GET requests accepting text/html
+type ExplorerConfig struct {
+	// Enabled serves the page, the GET requests of the browsers are executed as queries otherwise.
+	// It is off by default as the page documents the whole schema to anyone reaching the endpoint
+	Enabled bool `json:"-"`
+	// Title is the title of the page
+	Title string `json:"title"`
+	// Query is the query of the editor when the url of the page has none
+	Query string `json:"query"`
+	// Headers are the default headers of the requests sent by the page, i.e., an Authorization header
+	Headers map[string]string `json:"headers"`
+	// Assets are the files of a build of GraphiQL, i.e., embedded in the binary with go:embed. Their index.html replaces
+	// the generated page and the other files are served on the GET requests of the endpoint with the ExplorerAssetParam,
+	// i.e., `<script src="?explorer=graphiql.min.js">`
+	Assets fs.FS `json:"-"`
+	// Page replaces the page, the index.html of the Assets included. ExplorerConfigPlaceholder in it is replaced by the config as json
+	Page string `json:"-"`
+}
+
+const (
+	// ExplorerConfigPlaceholder is replaced by the ExplorerConfig as json in the page
+	ExplorerConfigPlaceholder = "EXPLORER_CONFIG"
+	// ExplorerAssetParam is the url parameter of the GET requests of the files of the Assets of the explorer
+	ExplorerAssetParam = "explorer"
+)
+
+// DefaultExplorerConfig is the ExplorerConfig of NewGqlServer
+var DefaultExplorerConfig = ExplorerConfig{
+	Title: "GraphQL Explorer",
+}
+
+// acceptsHTML tells whether the request comes from a browser navigating to the endpoint
+func acceptsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// serveExplorer serves the explorer page with its config
+func (g *GqlServer) serveExplorer(w http.ResponseWriter) {
+	config, err := json.Marshal(g.Explorer)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	page := g.Explorer.Page
+	if page == "" && g.Explorer.Assets != nil {
+		index, err := fs.ReadFile(g.Explorer.Assets, "index.html")
+		if err != nil {
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+		page = string(index)
+	}
+	if page == "" {
+		page = explorerPage
+	}
+	// json.Marshal escapes <, > and & so the config can not end the script it is set in
+	page = strings.Replace(page, ExplorerConfigPlaceholder, string(config), 1)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(page))
+}
+
+// serveExplorerAsset serves a file of the Assets of the explorer
+func (g *GqlServer) serveExplorerAsset(w http.ResponseWriter, r *http.Request, name string) {
+	// fs.ReadFile only reads the valid paths, which are relative and can not go up
+	data, err := fs.ReadFile(g.Explorer.Assets, name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
+
+const explorerPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>GraphQL Explorer</title>
+<style>
+  * { box-sizing: border-box; }
+  html, body { height: 100%; margin: 0; }
+  body { display: flex; flex-direction: column; font: 14px -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2933; }
+  header { display: flex; align-items: center; gap: 8px; padding: 8px 12px; background: #f3f4f6; border-bottom: 1px solid #d9dde3; }
+  header h1 { flex: 1; margin: 0; font-size: 16px; font-weight: 600; }
+  button { padding: 5px 12px; border: 1px solid #c4cad3; border-radius: 4px; background: #fff; cursor: pointer; font: inherit; }
+  button.run { background: #e10098; border-color: #e10098; color: #fff; font-weight: 600; }
+  main { flex: 1; display: flex; min-height: 0; }
+  section { display: flex; flex-direction: column; min-width: 0; border-right: 1px solid #d9dde3; }
+  .editors { flex: 1; }
+  .result { flex: 1; background: #fafbfc; }
+  .docs { width: 320px; display: none; overflow: auto; padding: 8px 12px; }
+  .docs.open { display: block; }
+  label { padding: 4px 12px; background: #f3f4f6; border-top: 1px solid #d9dde3; border-bottom: 1px solid #d9dde3; font-size: 12px; font-weight: 600; text-transform: uppercase; color: #616e7c; }
+  textarea, pre { flex: 1; margin: 0; padding: 8px 12px; border: 0; outline: 0; resize: none; overflow: auto; font: 13px/1.5 Menlo, Consolas, monospace; tab-size: 2; background: transparent; }
+  #query { flex: 3; }
+  .docs h2 { font-size: 15px; margin: 8px 0; }
+  .docs p { color: #616e7c; margin: 4px 0 8px; }
+  .docs ul { list-style: none; padding: 0; margin: 0; }
+  .docs li { padding: 3px 0; font-family: Menlo, Consolas, monospace; font-size: 12px; }
+  .docs a { color: #1f61a0; cursor: pointer; }
+  .docs .field { color: #0b7285; }
+  .docs .deprecated { text-decoration: line-through; }
+</style>
+</head>
+<body>
+<header>
+  <h1 id="title">GraphQL Explorer</h1>
+  <button class="run" id="run" title="Execute the query (Ctrl-Enter)">&#9654; Run</button>
+  <button id="stop" title="Stop the subscription" disabled>Stop</button>
+  <button id="toggle-docs">Docs</button>
+</header>
+<main>
+  <section class="editors">
+    <textarea id="query" spellcheck="false" placeholder="# Write a query, Ctrl-Enter runs it"></textarea>
+    <label for="variables">Variables</label>
+    <textarea id="variables" spellcheck="false" placeholder="{}"></textarea>
+    <label for="headers">Headers</label>
+    <textarea id="headers" spellcheck="false" placeholder="{}"></textarea>
+  </section>
+  <section class="result">
+    <pre id="result"></pre>
+  </section>
+  <section class="docs" id="docs"></section>
+</main>
+<script>
+(function () {
+  var config = EXPLORER_CONFIG;
+  var el = function (id) { return document.getElementById(id); };
+  var params = new URLSearchParams(location.search);
+
+  document.title = config.title;
+  el("title").textContent = config.title;
+  el("query").value = params.get("query") || config.query || "";
+  el("variables").value = params.get("variables") || "";
+  el("headers").value = config.headers ? JSON.stringify(config.headers, null, 2) : "";
+
+  var socket = null;
+
+  function show(value) {
+    el("result").textContent = typeof value === "string" ? value : JSON.stringify(value, null, 2);
+  }
+
+  function parseJSON(id) {
+    var text = el(id).value.trim();
+    if (!text) {
+      return {};
+    }
+    try {
+      return JSON.parse(text);
+    } catch (e) {
+      throw new Error("Invalid " + id + ": " + e.message);
     }
+  }
 
-    var m = map[string]interface{}{}
-    if i < vLen {
-      variable := variables[i]
-      if err := json.Unmarshal([]byte(variable), &m); err != nil {
-        return nil, &httpError{
-          status:  http.StatusBadRequest,
-          message: "Unable to read variables.",
-          error:   err,
+  function post(body, headers) {
+    var h = Object.assign({ "Content-Type": "application/json", "Accept": "application/json" }, headers);
+    return fetch(location.pathname, { method: "POST", headers: h, body: JSON.stringify(body) }).then(function (res) {
+      return res.text().then(function (text) {
+        try {
+          return JSON.parse(text);
+        } catch (e) {
+          throw new Error(res.status + " " + res.statusText + "\n" + text);
         }
-      }
+      });
+    });
+  }
+
+  function stop() {
+    if (socket) {
+      socket.close(1000);
+      socket = null;
     }
+    el("stop").disabled = true;
+  }
 
-    requests = append(requests, gqlRequest{q, opName, m})
+  // subscribe runs the operation over a graphql-transport-ws websocket, the headers being sent as the connection_init payload
+  function subscribe(body, headers) {
+    var url = (location.protocol === "https:" ? "wss://" : "ws://") + location.host + location.pathname;
+    var events = [];
+    socket = new WebSocket(url, "graphql-transport-ws");
+    el("stop").disabled = false;
+    socket.onopen = function () {
+      socket.send(JSON.stringify({ type: "connection_init", payload: headers }));
+    };
+    socket.onmessage = function (e) {
+      var msg = JSON.parse(e.data);
+      if (msg.type === "connection_ack") {
+        socket.send(JSON.stringify({ id: "1", type: "subscribe", payload: body }));
+        show("Waiting for events...");
+      } else if (msg.type === "next") {
+        events.unshift(msg.payload);
+        show(events);
+      } else if (msg.type === "error") {
+        show({ errors: msg.payload });
+      } else if (msg.type === "ping") {
+        socket.send(JSON.stringify({ type: "pong" }));
+      }
+    };
+    socket.onclose = function (e) {
+      if (e.code !== 1000) {
+        show((events.length ? JSON.stringify(events, null, 2) + "\n\n" : "") + "Connection closed: " + e.code + " " + e.reason);
+      }
+      el("stop").disabled = true;
+    };
   }
 
-  return &request{requests: requests, batch: qLen > 1}, nil
-}
+  function run() {
+    stop();
+    var body, headers;
+    try {
+      body = { query: el("query").value, variables: parseJSON("variables") };
+      headers = parseJSON("headers");
+    } catch (e) {
+      show(e.message);
+      return;
+    }
 
-func parsePost(r *http.Request) (*request, *httpError) {
+    var url = new URL(location.href);
+    url.searchParams.set("query", body.query);
+    if (el("variables").value.trim()) {
+      url.searchParams.set("variables", el("variables").value);
+    } else {
+      url.searchParams.delete("variables");
+    }
+    history.replaceState(null, "", url);
+
+    if (/^\s*subscription\b/.test(body.query.replace(/#.*$/gm, ""))) {
+      subscribe(body, headers);
+      return;
+    }
+    show("Loading...");
+    post(body, headers).then(show, function (e) { show(e.message); });
+  }
 
-  readBodyErr := &httpError{
-    status:  http.StatusBadRequest,
-    message: "Unable to read body.",
+  // the documentation explorer shows the types of the schema from its introspection
+  var introspection = "query IntrospectionQuery { __schema { queryType { name } mutationType { name } subscriptionType { name } " +
+    "types { kind name description fields(includeDeprecated: true) { name description isDeprecated deprecationReason " +
+    "args { name description type { ...Ref } defaultValue } type { ...Ref } } inputFields { name description type { ...Ref } defaultValue } " +
+    "interfaces { name } possibleTypes { name } enumValues(includeDeprecated: true) { name description isDeprecated } } } } " +
+    "fragment Ref on __Type { kind name ofType { kind name ofType { kind name ofType { kind name } } } }";
+  var schema = null;
+
+  function typeRef(t) {
+    if (t.kind === "NON_NULL") {
+      return typeRef(t.ofType).concat(["!"]);
+    }
+    if (t.kind === "LIST") {
+      return ["["].concat(typeRef(t.ofType), ["]"]);
+    }
+    return [link(t.name)];
   }
 
-  // read and close the body
-  body, err := ioutil.ReadAll(r.Body)
-  if err != nil {
-    readBodyErr.error = err
-    return nil, readBodyErr
+  function link(name) {
+    var a = document.createElement("a");
+    a.textContent = name;
+    a.onclick = function () { showType(name); };
+    return a;
   }
-  r.Body.Close()
 
-  if len(body) == 0 {
-    return nil, &httpError{
-      status:  http.StatusBadRequest,
-      message: "Missing request body.",
-      error:   errors.New("missing request body"),
+  function node(tag, children, className) {
+    var n = document.createElement(tag);
+    if (className) {
+      n.className = className;
     }
+    children.forEach(function (c) {
+      n.appendChild(typeof c === "string" ? document.createTextNode(c) : c);
+    });
+    return n;
   }
 
-  var requests []gqlRequest
-
-  // Graphql content type request will send only one query
-  if strings.HasPrefix(r.Header.Get("Content-Type"), ContentTypeGraphQL) {
-    req := gqlRequest{}
-    req.Query = string(body)
-    requests = append(requests, req)
-  } else {
-    // Inspect the first character to inform how the body is parsed.
-    switch body[0] {
-    case '{':
-      req := gqlRequest{}
-      if err := json.Unmarshal(body, &req); err != nil {
-        readBodyErr.error = err
-        return nil, readBodyErr
+  function list(items) {
+    return node("ul", items.map(function (item) { return node("li", item); }));
+  }
+
+  function showRoots() {
+    var docs = el("docs");
+    docs.textContent = "";
+    docs.appendChild(node("h2", ["Schema"]));
+    var roots = [["query", schema.queryType], ["mutation", schema.mutationType], ["subscription", schema.subscriptionType]];
+    docs.appendChild(list(roots.filter(function (r) { return r[1]; }).map(function (r) {
+      return [r[0] + ": ", link(r[1].name)];
+    })));
+    docs.appendChild(node("h2", ["Types"]));
+    docs.appendChild(list(schema.types.filter(function (t) { return t.name.indexOf("__") !== 0; }).map(function (t) {
+      return [link(t.name)];
+    })));
+  }
+
+  function showType(name) {
+    var t = schema.types.filter(function (t) { return t.name === name; })[0];
+    var docs = el("docs");
+    docs.textContent = "";
+    var back = link("< Schema");
+    back.onclick = showRoots;
+    docs.appendChild(back);
+    docs.appendChild(node("h2", [t.kind.toLowerCase().replace("_", " ") + " " + t.name]));
+    if (t.description) {
+      docs.appendChild(node("p", [t.description]));
+    }
+    if (t.interfaces && t.interfaces.length) {
+      docs.appendChild(node("p", ["implements "].concat(t.interfaces.map(function (i) { return link(i.name); }))));
+    }
+    if (t.possibleTypes && t.possibleTypes.length) {
+      docs.appendChild(node("p", ["possible types: "].concat(t.possibleTypes.map(function (p) { return link(p.name); }))));
+    }
+    var fields = t.fields || t.inputFields || [];
+    docs.appendChild(list(fields.map(function (f) {
+      var item = [node("span", [f.name], "field" + (f.isDeprecated ? " deprecated" : ""))];
+      if (f.args && f.args.length) {
+        item.push("(");
+        f.args.forEach(function (a, i) {
+          item.push((i ? ", " : "") + a.name + ": ");
+          item = item.concat(typeRef(a.type));
+        });
+        item.push(")");
       }
-      requests = append(requests, req)
-    case '[':
-      if err := json.Unmarshal(body, &requests); err != nil {
-        readBodyErr.error = err
-        return nil, readBodyErr
+      item.push(": ");
+      item = item.concat(typeRef(f.type));
+      if (f.description) {
+        item.push(node("p", [f.description]));
       }
+      return item;
+    })));
+    if (t.enumValues) {
+      docs.appendChild(list(t.enumValues.map(function (v) {
+        return [node("span", [v.name], v.isDeprecated ? "deprecated" : "")];
+      })));
     }
   }
 
-  return &request{requests: requests, batch: len(requests) > 1}, nil
-}
+  el("toggle-docs").onclick = function () {
+    var docs = el("docs");
+    docs.classList.toggle("open");
+    if (!docs.classList.contains("open") || schema) {
+      return;
+    }
+    var headers;
+    try {
+      headers = parseJSON("headers");
+    } catch (e) {
+      show(e.message);
+      return;
+    }
+    post({ query: introspection }, headers).then(function (res) {
+      if (!res.data) {
+        show(res);
+        return;
+      }
+      schema = res.data.__schema;
+      showRoots();
+    }, function (e) { show(e.message); });
+  };
+
+  el("run").onclick = run;
+  el("stop").onclick = stop;
+  document.addEventListener("keydown", function (e) {
+    if ((e.ctrlKey || e.metaKey) && e.key === "Enter") {
+      e.preventDefault();
+      run();
+    }
+  });
+  // the tab key indents in the editors
+  ["query", "variables", "headers"].forEach(function (id) {
+    el(id).addEventListener("keydown", function (e) {
+      if (e.key !== "Tab") {
+        return;
+      }
+      e.preventDefault();
+      var t = e.target, start = t.selectionStart;
+      t.value = t.value.slice(0, start) + "  " + t.value.slice(t.selectionEnd);
+      t.selectionStart = t.selectionEnd = start + 2;
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`
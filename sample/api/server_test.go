@@ -0,0 +1,249 @@
+package api
+
+import (
+  "bytes"
+  "context"
+  "encoding/json"
+  "net/http"
+  "net/http/httptest"
+  "strings"
+  "testing"
+  "time"
+
+  "github.com/gorilla/websocket"
+  graphql "github.com/graph-gophers/graphql-go"
+  gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// testResolver resolves the people of their id and publishes the people created to the subscriptions
+type testResolver struct {
+  pubsub *PubSub
+}
+
+func newTestResolver() *testResolver {
+  return &testResolver{pubsub: NewPubSub()}
+}
+
+func (r *testResolver) Person(ctx context.Context, args QueryPersonArgs) (PersonResolver, error) {
+  return PersonResolver{R: &Person{ID: args.ID, Name: "Person " + args.ID}}, nil
+}
+
+func (r *testResolver) Search(ctx context.Context, args QuerySearchArgs) ([]*SearchResultResolver, error) {
+  return nil, nil
+}
+
+func (r *testResolver) Nodes(ctx context.Context, args QueryNodesArgs) ([]*NodeResolver, error) {
+  return nil, nil
+}
+
+func (r *testResolver) CreatePerson(ctx context.Context, args MutationCreatePersonArgs) (PersonResolver, error) {
+  p := &Person{ID: args.Person.Name, Name: args.Person.Name, Email: args.Person.Email}
+  r.pubsub.Publish("personCreated", p)
+  return PersonResolver{R: p}, nil
+}
+
+func (r *testResolver) CreateFolder(ctx context.Context, args MutationCreateFolderArgs) (FolderResolver, error) {
+  return FolderResolver{R: &Folder{Name: args.Folder.Name}}, nil
+}
+
+func (r *testResolver) CreateFile(ctx context.Context, args MutationCreateFileArgs) (FileResolver, error) {
+  return FileResolver{R: &File{Name: args.File.Name}}, nil
+}
+
+func (r *testResolver) PersonCreated(ctx context.Context) (<-chan PersonResolver, error) {
+  events := r.pubsub.Subscribe(ctx, "personCreated")
+  people := make(chan PersonResolver)
+  go func() {
+    defer close(people)
+    for e := range events {
+      select {
+      case people <- PersonResolver{R: e.(*Person)}:
+      case <-ctx.Done():
+        return
+      }
+    }
+  }()
+  return people, nil
+}
+
+// newTestServer serves the GqlServer of a testResolver, configured by the function
+func newTestServer(t *testing.T, configure func(g *GqlServer)) (*testResolver, *httptest.Server) {
+  r := newTestResolver()
+  g := NewGqlServer(r, "0", nil)
+  if configure != nil {
+    configure(g)
+  }
+  return r, serve(t, g)
+}
+
+// serve serves the handler of the server until the end of the test
+func serve(t *testing.T, g *GqlServer) *httptest.Server {
+  srv := httptest.NewServer(g.Handler())
+  t.Cleanup(srv.Close)
+  return srv
+}
+
+// post sends the body to the endpoint of the server and decodes the response into result
+func post(t *testing.T, srv *httptest.Server, body string, result interface{}) int {
+  res, err := http.Post(srv.URL+DefaultPath, "application/json", strings.NewReader(body))
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer res.Body.Close()
+  var buf bytes.Buffer
+  buf.ReadFrom(res.Body)
+  if result != nil {
+    if err := json.Unmarshal(buf.Bytes(), result); err != nil {
+      t.Fatalf("response %q: %v", buf.String(), err)
+    }
+  }
+  return res.StatusCode
+}
+
+type testResponse struct {
+  Data   map[string]interface{} `json:"data"`
+  Errors []struct {
+    Message    string                 `json:"message"`
+    Extensions map[string]interface{} `json:"extensions"`
+  } `json:"errors"`
+}
+
+// dialWebSocket opens an acknowledged websocket connection to the server
+func dialWebSocket(t *testing.T, srv *httptest.Server) *websocket.Conn {
+  dialer := websocket.Dialer{Subprotocols: []string{WebSocketProtocol}}
+  conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+DefaultPath, nil)
+  if err != nil {
+    t.Fatal(err)
+  }
+  t.Cleanup(func() { conn.Close() })
+
+  writeMessage(t, conn, wsMessage{Type: wsConnectionInit})
+  if msg := readMessage(t, conn); msg.Type != wsConnectionAck {
+    t.Fatalf("received %q, want %q", msg.Type, wsConnectionAck)
+  }
+  return conn
+}
+
+func writeMessage(t *testing.T, conn *websocket.Conn, msg wsMessage) {
+  if err := conn.WriteJSON(msg); err != nil {
+    t.Fatal(err)
+  }
+}
+
+func subscribe(t *testing.T, conn *websocket.Conn, id, query string) {
+  payload, _ := json.Marshal(map[string]string{"query": query})
+  writeMessage(t, conn, wsMessage{ID: id, Type: wsSubscribe, Payload: payload})
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) wsMessage {
+  conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+  var msg wsMessage
+  if err := conn.ReadJSON(&msg); err != nil {
+    t.Fatal(err)
+  }
+  return msg
+}
+
+// waitSubscribers waits for the topic to have n subscribers
+func waitSubscribers(t *testing.T, ps *PubSub, topic string, n int) {
+  for start := time.Now(); ; time.Sleep(time.Millisecond) {
+    ps.mu.RLock()
+    count := len(ps.topics[topic])
+    ps.mu.RUnlock()
+    if count == n {
+      return
+    }
+    if time.Since(start) > 2*time.Second {
+      t.Fatalf("%d subscribers to %s, want %d", count, topic, n)
+    }
+  }
+}
+
+// rejectMutations is an interceptor rejecting the mutations
+func rejectMutations(ctx context.Context, req *GqlRequest, next OperationHandler) *graphql.Response {
+  if strings.HasPrefix(strings.TrimSpace(req.Query), "mutation") {
+    return &graphql.Response{Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("mutations are disabled")}}
+  }
+  return next(ctx, req)
+}
+
+func TestInterceptorRejectsHTTPMutation(t *testing.T) {
+  r, srv := newTestServer(t, func(g *GqlServer) {
+    g.Interceptors = append(g.Interceptors, rejectMutations)
+  })
+  events := r.pubsub.Subscribe(context.Background(), "personCreated")
+
+  var res testResponse
+  post(t, srv, `{"query": "mutation { createPerson(person: {name: \"Rey\", email: \"rey@resistance\"}) { id } }"}`, &res)
+  if len(res.Errors) != 1 || res.Errors[0].Message != "mutations are disabled" {
+    t.Errorf("received %+v, want the mutation to be rejected", res)
+  }
+  select {
+  case e := <-events:
+    t.Errorf("the rejected mutation published %v", e)
+  default:
+  }
+}
+
+func TestInterceptorRejectsWebSocketMutation(t *testing.T) {
+  r, srv := newTestServer(t, func(g *GqlServer) {
+    g.Interceptors = append(g.Interceptors, rejectMutations)
+  })
+  events := r.pubsub.Subscribe(context.Background(), "personCreated")
+  conn := dialWebSocket(t, srv)
+
+  subscribe(t, conn, "1", `mutation { createPerson(person: {name: "Rey", email: "rey@resistance"}) { id } }`)
+  msg := readMessage(t, conn)
+  if msg.ID != "1" || msg.Type != wsError || !strings.Contains(string(msg.Payload), "mutations are disabled") {
+    t.Errorf("received %s %s %s, want the mutation to be rejected", msg.ID, msg.Type, msg.Payload)
+  }
+  select {
+  case e := <-events:
+    t.Errorf("the rejected mutation published %v", e)
+  default:
+  }
+
+  // the other operations still go through
+  subscribe(t, conn, "2", `{ person(id: "1") { name } }`)
+  if msg := readMessage(t, conn); msg.Type != wsNext || string(msg.Payload) != `{"data":{"person":{"name":"Person 1"}}}` {
+    t.Errorf("received %s %s, want the person", msg.Type, msg.Payload)
+  }
+  if msg := readMessage(t, conn); msg.Type != wsComplete {
+    t.Errorf("received %s, want %s", msg.Type, wsComplete)
+  }
+}
+
+func TestInterceptorSeesSubscription(t *testing.T) {
+  started := make(chan string, 1)
+  r, srv := newTestServer(t, func(g *GqlServer) {
+    g.Interceptors = append(g.Interceptors, func(ctx context.Context, req *GqlRequest, next OperationHandler) *graphql.Response {
+      started <- req.Query
+      return next(ctx, req)
+    })
+  })
+  conn := dialWebSocket(t, srv)
+
+  subscribe(t, conn, "1", `subscription { personCreated { name } }`)
+  select {
+  case q := <-started:
+    if q != `subscription { personCreated { name } }` {
+      t.Errorf("the interceptor got %q", q)
+    }
+  case <-time.After(2 * time.Second):
+    t.Fatal("the subscription did not go through the interceptor")
+  }
+  // the events sent after the first one do not go through the interceptors again
+  waitSubscribers(t, r.pubsub, "personCreated", 1)
+  for _, name := range []string{"Rey", "Finn"} {
+    r.pubsub.Publish("personCreated", &Person{Name: name})
+    msg := readMessage(t, conn)
+    if want := `{"data":{"personCreated":{"name":"` + name + `"}}}`; msg.Type != wsNext || string(msg.Payload) != want {
+      t.Errorf("received %s %s, want %s", msg.Type, msg.Payload, want)
+    }
+  }
+  select {
+  case q := <-started:
+    t.Errorf("the interceptor ran again for %q", q)
+  default:
+  }
+}
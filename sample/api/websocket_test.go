@@ -0,0 +1,136 @@
+package api
+
+import (
+  "encoding/json"
+  "strings"
+  "testing"
+  "time"
+
+  "github.com/gorilla/websocket"
+)
+
+// expectClose reads the messages of the connection until the server closes it with the code
+func expectClose(t *testing.T, conn *websocket.Conn, code int) {
+  conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+  for {
+    _, _, err := conn.ReadMessage()
+    if err == nil {
+      continue
+    }
+    if !websocket.IsCloseError(err, code) {
+      t.Errorf("the connection ended with %v, want the close code %d", err, code)
+    }
+    return
+  }
+}
+
+func TestWebSocketSubscription(t *testing.T) {
+  r, srv := newTestServer(t, nil)
+  conn := dialWebSocket(t, srv)
+
+  writeMessage(t, conn, wsMessage{Type: wsPing, Payload: json.RawMessage(`{"n":1}`)})
+  if msg := readMessage(t, conn); msg.Type != wsPong || string(msg.Payload) != `{"n":1}` {
+    t.Errorf("received %s %s, want the pong of the ping", msg.Type, msg.Payload)
+  }
+
+  subscribe(t, conn, "1", `subscription { personCreated { name } }`)
+  waitSubscribers(t, r.pubsub, "personCreated", 1)
+
+  // the mutations sent over http publish to the subscription
+  for _, name := range []string{"Rey", "Finn"} {
+    body := `{"query": "mutation { createPerson(person: {name: \"` + name + `\", email: \"\"}) { id } }"}`
+    if status := post(t, srv, body, nil); status != 200 {
+      t.Fatalf("the mutation got the status %d", status)
+    }
+    msg := readMessage(t, conn)
+    if want := `{"data":{"personCreated":{"name":"` + name + `"}}}`; msg.ID != "1" || msg.Type != wsNext || string(msg.Payload) != want {
+      t.Errorf("received %s %s %s, want %s", msg.ID, msg.Type, msg.Payload, want)
+    }
+  }
+
+  // the client completing the subscription unsubscribes it without a complete message
+  writeMessage(t, conn, wsMessage{ID: "1", Type: wsComplete})
+  waitSubscribers(t, r.pubsub, "personCreated", 0)
+
+  subscribe(t, conn, "2", `{ person(id: "1") { name } }`)
+  if msg := readMessage(t, conn); msg.ID != "2" || msg.Type != wsNext {
+    t.Errorf("received %s %s %s, want the result of the query", msg.ID, msg.Type, msg.Payload)
+  }
+  if msg := readMessage(t, conn); msg.ID != "2" || msg.Type != wsComplete {
+    t.Errorf("received %s %s, want the query to complete", msg.ID, msg.Type)
+  }
+}
+
+func TestWebSocketInvalidOperation(t *testing.T) {
+  _, srv := newTestServer(t, nil)
+  conn := dialWebSocket(t, srv)
+
+  subscribe(t, conn, "1", `subscription { unknown }`)
+  msg := readMessage(t, conn)
+  if msg.ID != "1" || msg.Type != wsError || !strings.Contains(string(msg.Payload), "unknown") {
+    t.Errorf("received %s %s %s, want the validation error", msg.ID, msg.Type, msg.Payload)
+  }
+
+  // the connection stays usable
+  subscribe(t, conn, "2", `{ person(id: "1") { name } }`)
+  if msg := readMessage(t, conn); msg.ID != "2" || msg.Type != wsNext {
+    t.Errorf("received %s %s %s, want the result of the query", msg.ID, msg.Type, msg.Payload)
+  }
+}
+
+func TestWebSocketProtocolErrors(t *testing.T) {
+  _, srv := newTestServer(t, nil)
+  url := "ws" + strings.TrimPrefix(srv.URL, "http") + DefaultPath
+
+  dial := func(protocols ...string) *websocket.Conn {
+    dialer := websocket.Dialer{Subprotocols: protocols}
+    conn, _, err := dialer.Dial(url, nil)
+    if err != nil {
+      t.Fatal(err)
+    }
+    t.Cleanup(func() { conn.Close() })
+    return conn
+  }
+
+  t.Run("subprotocol", func(t *testing.T) {
+    expectClose(t, dial(), 4406)
+  })
+
+  t.Run("subscribe before init", func(t *testing.T) {
+    conn := dial(WebSocketProtocol)
+    subscribe(t, conn, "1", `{ person(id: "1") { name } }`)
+    expectClose(t, conn, 4401)
+  })
+
+  t.Run("init twice", func(t *testing.T) {
+    conn := dialWebSocket(t, srv)
+    writeMessage(t, conn, wsMessage{Type: wsConnectionInit})
+    expectClose(t, conn, 4429)
+  })
+
+  t.Run("invalid message", func(t *testing.T) {
+    conn := dialWebSocket(t, srv)
+    conn.WriteMessage(websocket.TextMessage, []byte("not json"))
+    expectClose(t, conn, 4400)
+  })
+
+  t.Run("duplicate id", func(t *testing.T) {
+    conn := dialWebSocket(t, srv)
+    subscribe(t, conn, "1", `subscription { personCreated { name } }`)
+    subscribe(t, conn, "1", `subscription { personCreated { name } }`)
+    expectClose(t, conn, 4409)
+  })
+}
+
+func TestWebSocketClose(t *testing.T) {
+  r, srv := newTestServer(t, nil)
+  conn := dialWebSocket(t, srv)
+
+  subscribe(t, conn, "1", `subscription { personCreated { name } }`)
+  subscribe(t, conn, "2", `subscription { personCreated { name } }`)
+  waitSubscribers(t, r.pubsub, "personCreated", 2)
+
+  // closing the connection cancels its operations
+  conn.Close()
+  waitSubscribers(t, r.pubsub, "personCreated", 0)
+}
@@ -0,0 +1,274 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+type PersonInput struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// GetPersonDocument is the query sent by GetPerson
+const GetPersonDocument = `query getPerson($id: ID!, $first: Int = 10) {
+  person(id: $id) {
+    ...PersonFields
+    friends(first: $first) {
+      ...PersonFields
+    }
+  }
+}
+
+fragment PersonFields on Person {
+  id
+  name
+}`
+
+// GetPersonVariables are the variables of the getPerson query
+type GetPersonVariables struct {
+	ID    string `json:"id"`
+	First *int32 `json:"first,omitempty"`
+}
+
+// GetPersonResult is the result of the getPerson query
+type GetPersonResult struct {
+	Person GetPersonResultPerson `json:"person"`
+}
+
+type GetPersonResultPerson struct {
+	ID      string                          `json:"id"`
+	Name    string                          `json:"name"`
+	Friends []*GetPersonResultPersonFriends `json:"friends"`
+}
+
+type GetPersonResultPersonFriends struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// GetPerson sends the getPerson query, the data resolved despite of errors is returned along with them
+func (c *Client) GetPerson(ctx context.Context, vars GetPersonVariables) (*GetPersonResult, error) {
+	var result GetPersonResult
+	err := c.Do(ctx, GetPersonDocument, "getPerson", vars, &result)
+	return &result, err
+}
+
+// CreatePersonDocument is the mutation sent by CreatePerson
+const CreatePersonDocument = `mutation createPerson($person: PersonInput!) {
+  created: createPerson(person: $person) {
+    ...PersonFields
+    email
+  }
+}
+
+fragment PersonFields on Person {
+  id
+  name
+}`
+
+// CreatePersonVariables are the variables of the createPerson mutation
+type CreatePersonVariables struct {
+	Person PersonInput `json:"person"`
+}
+
+// CreatePersonResult is the result of the createPerson mutation
+type CreatePersonResult struct {
+	Created CreatePersonResultCreated `json:"created"`
+}
+
+type CreatePersonResultCreated struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// CreatePerson sends the createPerson mutation, the data resolved despite of errors is returned along with them
+func (c *Client) CreatePerson(ctx context.Context, vars CreatePersonVariables) (*CreatePersonResult, error) {
+	var result CreatePersonResult
+	err := c.Do(ctx, CreatePersonDocument, "createPerson", vars, &result)
+	return &result, err
+}
+
+// SearchDocument is the query sent by Search
+const SearchDocument = `query search($text: String!) {
+  search(text: $text) {
+    __typename
+    ... on Folder {
+      id
+      name
+      files {
+        name
+      }
+    }
+    ... on File {
+      id
+      fileName: name
+      folder {
+        name
+      }
+    }
+  }
+  nodes(name: $text) {
+    __typename
+    id
+    name
+    ... on Person {
+      email
+    }
+  }
+}`
+
+// SearchVariables are the variables of the search query
+type SearchVariables struct {
+	Text string `json:"text"`
+}
+
+// SearchResult is the result of the search query
+type SearchResult struct {
+	Search []*SearchResultSearch `json:"search"`
+	Nodes  []*SearchResultNodes  `json:"nodes"`
+}
+
+type SearchResultSearch struct {
+	Typename string                     `json:"__typename"`
+	ID       *string                    `json:"id"`
+	Name     *string                    `json:"name"`
+	Files    []*SearchResultSearchFiles `json:"files"`
+	FileName *string                    `json:"fileName"`
+	Folder   *SearchResultSearchFolder  `json:"folder"`
+}
+
+type SearchResultSearchFiles struct {
+	Name string `json:"name"`
+}
+
+type SearchResultSearchFolder struct {
+	Name string `json:"name"`
+}
+
+type SearchResultNodes struct {
+	Typename string  `json:"__typename"`
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Email    *string `json:"email"`
+}
+
+// Search sends the search query, the data resolved despite of errors is returned along with them
+func (c *Client) Search(ctx context.Context, vars SearchVariables) (*SearchResult, error) {
+	var result SearchResult
+	err := c.Do(ctx, SearchDocument, "search", vars, &result)
+	return &result, err
+}
+
+// Client sends the operations to a GraphQL endpoint with the json format of GqlServer
+type Client struct {
+	Endpoint   string
+	HTTPClient *http.Client
+	// Header is added to every request i.e., Authorization
+	Header http.Header
+}
+
+// NewClient creates a client of the endpoint using the default http client
+func NewClient(endpoint string) *Client {
+	return &Client{
+		Endpoint:   endpoint,
+		HTTPClient: http.DefaultClient,
+		Header:     http.Header{},
+	}
+}
+
+// ClientError is an error of a GraphQL response
+type ClientError struct {
+	Message   string `json:"message"`
+	Locations []struct {
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	} `json:"locations,omitempty"`
+	Path []interface{} `json:"path,omitempty"`
+}
+
+func (e *ClientError) Error() string {
+	return "graphql: " + e.Message
+}
+
+// ClientErrors are the errors of a GraphQL response
+type ClientErrors []*ClientError
+
+func (errs ClientErrors) Error() string {
+	var msgs []string
+	for _, e := range errs {
+		msgs = append(msgs, e.Message)
+	}
+	return "graphql: " + strings.Join(msgs, "; ")
+}
+
+type clientRequest struct {
+	Query         string      `json:"query"`
+	OperationName string      `json:"operationName"`
+	Variables     interface{} `json:"variables,omitempty"`
+}
+
+// Do posts an operation to the endpoint and decodes the data of the response into result.
+// The errors of the response are returned as ClientErrors
+func (c *Client) Do(ctx context.Context, query, operationName string, variables, result interface{}) error {
+	body, err := json.Marshal(clientRequest{
+		Query:         query,
+		OperationName: operationName,
+		Variables:     variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for name, values := range c.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("graphql: %s: %s", res.Status, bytes.TrimSpace(data))
+	}
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors ClientErrors    `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return err
+	}
+	if len(response.Data) > 0 && string(response.Data) != "null" && result != nil {
+		if err := json.Unmarshal(response.Data, result); err != nil {
+			return err
+		}
+	}
+	if len(response.Errors) > 0 {
+		return response.Errors
+	}
+	return nil
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
+  "context"
+  "errors"
+  "os"
+  "os/signal"
   "strconv"
   "strings"
+  "syscall"
 
   "github.com/dealtap/graphql-gen-go/sample/api"
+  "github.com/graph-gophers/graphql-go"
 )
 
 const Port = "7050"
@@ -67,19 +73,38 @@ var files = []*api.File{
   },
 }
 
-type resolver struct{}
+const PersonCreatedTopic = "personCreated"
 
-func (r *resolver) Person(request api.PersonRequest) api.PersonResolver {
-  var p *api.Person
-  for _, prs := range people {
-    if prs.ID == request.ID {
-      p = prs
+type resolver struct {
+  pubsub *api.PubSub
+}
+
+// fetchPeople is the batch function of the Person loader
+func fetchPeople(ctx context.Context, ids []string) ([]*api.Person, error) {
+  result := make([]*api.Person, len(ids))
+  for i, id := range ids {
+    for _, prs := range people {
+      if prs.ID == id {
+        result[i] = prs
+      }
     }
   }
-  return api.PersonResolver{p}
+  return result, nil
+}
+
+func (r *resolver) Person(ctx context.Context, request api.QueryPersonArgs) (api.PersonResolver, error) {
+  // the people requested by the operations of a batch are fetched at once
+  p, err := api.LoadersFrom(ctx).Person.Load(ctx, request.ID)
+  if err != nil {
+    return api.PersonResolver{}, err
+  }
+  if p == nil {
+    return api.PersonResolver{}, errors.New("no person with id " + request.ID)
+  }
+  return api.PersonResolver{R: p}, nil
 }
 
-func (r *resolver) CreateFile(request api.CreateFileRequest) api.FileResolver {
+func (r *resolver) CreateFile(ctx context.Context, request api.MutationCreateFileArgs) (api.FileResolver, error) {
 
   var f *api.File
 
@@ -102,20 +127,20 @@ func (r *resolver) CreateFile(request api.CreateFileRequest) api.FileResolver {
     }
   }
 
-  return api.FileResolver{f}
+  return api.FileResolver{R: f}, nil
 }
 
-func (r *resolver) CreateFolder(request api.CreateFolderRequest) api.FolderResolver {
+func (r *resolver) CreateFolder(ctx context.Context, request api.MutationCreateFolderArgs) (api.FolderResolver, error) {
   id := UniqueIdBase + len(folders)
   f := &api.Folder{
     ID:   strconv.Itoa(id),
     Name: request.Folder.Name,
   }
   folders = append(folders, f)
-  return api.FolderResolver{f}
+  return api.FolderResolver{R: f}, nil
 }
 
-func (r *resolver) CreatePerson(request api.CreatePersonRequest) api.PersonResolver {
+func (r *resolver) CreatePerson(ctx context.Context, request api.MutationCreatePersonArgs) (api.PersonResolver, error) {
   id := UniqueIdBase + len(people)
   p := &api.Person{
     ID:    strconv.Itoa(id),
@@ -123,26 +148,68 @@ func (r *resolver) CreatePerson(request api.CreatePersonRequest) api.PersonResol
     Email: request.Person.Email,
   }
   people = append(people, p)
-  return api.PersonResolver{p}
+  r.pubsub.Publish(PersonCreatedTopic, p)
+  return api.PersonResolver{R: p}, nil
 }
 
-func (r *resolver) Search(request api.SearchRequest) []*api.SearchResultResolver {
+func (r *resolver) PersonCreated(ctx context.Context) (<-chan api.PersonResolver, error) {
+  events := r.pubsub.Subscribe(ctx, PersonCreatedTopic)
+  c := make(chan api.PersonResolver)
+  go func() {
+    defer close(c)
+    for e := range events {
+      select {
+      case c <- api.PersonResolver{R: e.(*api.Person)}:
+      case <-ctx.Done():
+        return
+      }
+    }
+  }()
+  return c, nil
+}
+
+func (r *resolver) Search(ctx context.Context, request api.QuerySearchArgs) ([]*api.SearchResultResolver, error) {
 
   var result []*api.SearchResultResolver
 
   for _, f := range files {
     if strings.Contains(f.Name, request.Text) {
-      result = append(result, &api.SearchResultResolver{&api.FileResolver{f}})
+      result = append(result, &api.SearchResultResolver{Result: &api.FileResolver{R: f}})
     }
   }
 
   for _, f := range folders {
     if strings.Contains(f.Name, request.Text) {
-      result = append(result, &api.SearchResultResolver{&api.FolderResolver{f}})
+      result = append(result, &api.SearchResultResolver{Result: &api.FolderResolver{R: f}})
+    }
+  }
+
+  return result, nil
+}
+
+func (r *resolver) Nodes(ctx context.Context, request api.QueryNodesArgs) ([]*api.NodeResolver, error) {
+
+  var result []*api.NodeResolver
+
+  for _, p := range people {
+    if strings.Contains(p.Name, request.Name) {
+      result = append(result, api.NewNodeResolver(p))
+    }
+  }
+
+  for _, f := range folders {
+    if strings.Contains(f.Name, request.Name) {
+      result = append(result, api.NewNodeResolver(f))
+    }
+  }
+
+  for _, f := range files {
+    if strings.Contains(f.Name, request.Name) {
+      result = append(result, api.NewNodeResolver(f))
     }
   }
 
-  return result
+  return result, nil
 }
 
 func main() {
@@ -163,8 +230,18 @@ func main() {
     people[3],
   }
 
-  gqlSrv := api.NewGqlServer(&resolver{}, Port, nil)
-  err := gqlSrv.Serve()
+  // the depth limit leaves room for the IntrospectionQuery, which is 13 levels deep
+  gqlSrv := api.NewGqlServer(&resolver{pubsub: api.NewPubSub()}, Port, nil, graphql.MaxDepth(15))
+  gqlSrv.Fetchers.Person = fetchPeople
+  gqlSrv.MaxQuerySize = 10000
+  gqlSrv.MaxCost = 1000
+  gqlSrv.Explorer.Enabled = true
+  gqlSrv.Explorer.Query = `{ person(id: "1000") { name friends { name } } }`
+
+  // serve until interrupted, then let the requests in flight finish
+  ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+  defer stop()
+  err := gqlSrv.NewServer(":" + Port).Run(ctx)
   if err != nil {
     panic(err)
   }